@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/Ja7ad/meilisitemap/internal/generator"
+	"github.com/Ja7ad/meilisitemap/internal/logger"
+	"github.com/Ja7ad/meilisitemap/internal/preflight"
+	"github.com/Ja7ad/meilisitemap/internal/report"
+	"github.com/Ja7ad/meilisitemap/internal/server"
+	"github.com/Ja7ad/meilisitemap/internal/tracing"
+	"github.com/Ja7ad/meilisitemap/internal/validator"
+	"github.com/meilisearch/meilisearch-go"
+)
+
+func runCmd(ctx context.Context, log logger.Logger, args []string) int {
+	fs, f := newFlagSet("run", true)
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	return startGenerator(ctx, log, f)
+}
+
+func generateCmd(ctx context.Context, log logger.Logger, args []string) int {
+	fs, f := newFlagSet("generate", true)
+	once := fs.Bool("once", false, "generate sitemaps a single time then exit, regardless of serve and live_update")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if *once {
+		return startGenerator(ctx, log, f, generator.WithOnce())
+	}
+
+	return startGenerator(ctx, log, f)
+}
+
+func dryRunCmd(ctx context.Context, log logger.Logger, args []string) int {
+	fs, f := newFlagSet("dry-run", false)
+	samples := fs.Int("samples", 5, "number of sample urls printed for every sitemap")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	return startGenerator(ctx, log, f, generator.WithDryRun(os.Stdout, *samples))
+}
+
+func startGenerator(ctx context.Context, log logger.Logger, f *commonFlags, opts ...generator.Option) int {
+	cfg, _, err := loadConfig(log, *f.configPath, *f.strict)
+	if err != nil {
+		return exitInvalidConfig
+	}
+
+	shutdown, err := tracing.Setup(ctx, cfg.General.Tracing)
+	if err != nil {
+		log.Error("failed to setup tracing", "err", err)
+		return exitFailure
+	}
+	defer func() {
+		if err := shutdown(context.Background()); err != nil {
+			log.Error("failed to flush traces", "err", err)
+		}
+	}()
+
+	storePath := _defaultStoreDir
+	if f.storePath != nil {
+		storePath = *f.storePath
+		if err := ensureStore(log, storePath); err != nil {
+			return exitFailure
+		}
+	}
+
+	sm, err := generator.New(
+		ctx,
+		storePath,
+		cfg.General,
+		log,
+		cfg.Sitemaps,
+		opts...,
+	)
+	if err != nil {
+		log.Error("failed to initialize generator", "err", err)
+		return exitFailure
+	}
+
+	if err := sm.Start(); err != nil {
+		log.Error("failed to start sitemap", "err", err)
+		if errors.Is(err, preflight.ErrFatalMismatch) {
+			return exitPreflight
+		}
+		return exitFailure
+	}
+
+	return exitOK
+}
+
+func validateCmd(ctx context.Context, log logger.Logger, args []string) int {
+	fs, f := newFlagSet("validate", false)
+	withPreflight := fs.Bool("preflight", false, "verify field maps against live meilisearch indexes")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	cfg, issues, err := loadConfig(log, *f.configPath, *f.strict)
+	printIssues(issues)
+	if err != nil {
+		return exitInvalidConfig
+	}
+
+	fmt.Printf("config: ok, %d warning(s)\n", len(issues.Warnings()))
+
+	if !*withPreflight {
+		return exitOK
+	}
+
+	client := meilisearch.New(cfg.General.MeiliSearch.Host, meilisearch.WithAPIKey(cfg.General.MeiliSearch.APIKey))
+	defer client.Close()
+
+	if !client.IsHealthy() {
+		log.Error("meilisearch is unreachable", "host", cfg.General.MeiliSearch.Host)
+		return exitPreflight
+	}
+
+	report := preflight.Run(ctx, client, cfg.Sitemaps)
+	printPreflight(report)
+
+	if err := report.Err(); err != nil {
+		log.Error("preflight failed", "err", err)
+		return exitPreflight
+	}
+
+	return exitOK
+}
+
+func serveCmd(ctx context.Context, log logger.Logger, args []string) int {
+	fs, f := newFlagSet("serve", true)
+	listen := fs.String("listen", "", "listen address, overrides serve.listen of config")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	cfg, _, err := loadConfig(log, *f.configPath, *f.strict)
+	if err != nil {
+		return exitInvalidConfig
+	}
+
+	serveCfg := new(config.ServeConfig)
+	if cfg.General.Serve != nil {
+		*serveCfg = *cfg.General.Serve
+	}
+
+	if *listen != "" {
+		serveCfg.Listen = *listen
+	}
+
+	if serveCfg.Listen == "" {
+		log.Error("listen address is required, set serve.listen in config or -listen flag")
+		return exitUsage
+	}
+
+	if _, err := os.Stat(*f.storePath); err != nil {
+		log.Error("sitemap store is not accessible", "store", *f.storePath, "err", err)
+		return exitFailure
+	}
+
+	srv, err := server.New(serveCfg, *f.storePath)
+	if err != nil {
+		log.Error("failed to create server", "err", err)
+		return exitFailure
+	}
+
+	srv.HandlePrivate("GET /api/report", report.Handler(*f.storePath))
+	srv.Start()
+	log.Info("sitemaps served", "addr", srv.URL(), "admin_addr", srv.AdminAddr())
+
+	select {
+	case <-ctx.Done():
+		_ = srv.Shutdown(context.Background())
+		return exitOK
+	case err := <-srv.Notify():
+		log.Error("server failed", "err", err)
+		return exitFailure
+	}
+}
+
+func checkCmd(log logger.Logger, args []string) int {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	host := fs.String("host", "", "host every loc must be on, empty skip host check")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: meilisitemap check [-host example.com] <file>...")
+		return exitUsage
+	}
+
+	code := exitOK
+
+	for _, path := range fs.Args() {
+		res, err := validator.ValidateFile(path, validator.Options{Host: *host})
+		if err != nil {
+			log.Error("failed to check sitemap", "file", path, "err", err)
+			code = exitNotConform
+			continue
+		}
+
+		fmt.Printf("%s: %s entries=%d bytes=%d problems=%d\n", path, res.Kind, res.Entries, res.Bytes, len(res.Problems))
+		for _, p := range res.Problems {
+			fmt.Printf("  %s\n", p)
+		}
+
+		if len(res.Problems) != 0 {
+			code = exitNotConform
+		}
+	}
+
+	return code
+}
+
+func printIssues(issues config.Issues) {
+	for _, i := range issues {
+		fmt.Printf("%s: %s\n", i.Severity, i.Error())
+	}
+}
+
+func printPreflight(report *preflight.Report) {
+	for _, idx := range report.Indexes {
+		fmt.Printf("preflight %s: documents=%d sampled=%d\n", idx.Index, idx.Documents, idx.Sampled)
+		for _, c := range idx.Checks {
+			fmt.Printf("  %s: %s\n", c.Level, c.String())
+		}
+	}
+}
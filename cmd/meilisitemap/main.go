@@ -3,54 +3,50 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"github.com/Ja7ad/meilisitemap/config"
-	"github.com/Ja7ad/meilisitemap/internal/generator"
 	"github.com/Ja7ad/meilisitemap/internal/logger"
 )
 
 const _defaultStoreDir = "sitemap"
 
-func main() {
-	configPath := flag.String("config", "./config.json", "path to config file")
-	storePath := flag.String("store", _defaultStoreDir, "path to store sitemap")
-	flag.Parse()
+// exit codes of meilisitemap, useful for CI pipelines.
+const (
+	exitOK            = 0
+	exitFailure       = 1 // generation or server failed
+	exitUsage         = 2 // unknown command or invalid flags
+	exitInvalidConfig = 3 // config file not loaded or has errors
+	exitPreflight     = 4 // meilisearch unreachable or field map not match indexes
+	exitNotConform    = 5 // sitemap file does not conform to sitemap protocol
+)
 
-	ctx, cancel := context.WithCancel(context.Background())
+const usage = `Usage: meilisitemap [command] [flags]
 
-	log := logger.DefaultLogger
+Commands:
+  run        generate sitemaps and keep serving and updating them (default)
+  generate   generate sitemaps, with -once exit after a single run
+  validate   validate config, with -preflight verify field maps against meilisearch
+  dry-run    fetch and build sitemaps, print stats and sample urls without writing
+  serve      only serve an existing sitemap store
+  check      check existing sitemap files conform to sitemap protocol
 
-	cfg, err := config.New(*configPath)
-	if err != nil {
-		log.Fatal("failed to load config", "err", err)
-	}
+Run 'meilisitemap <command> -h' for flags of command.
+`
 
-	if err := cfg.Validate(); err != nil {
-		log.Fatal("invalid config", "err", err)
-	}
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
 
-	log.Info("configuration file loaded")
+func run(args []string) int {
+	log := logger.DefaultLogger
 
-	if _, err := os.Stat(*storePath); os.IsNotExist(err) {
-		if err := os.Mkdir(*storePath, 0o777); err != nil {
-			log.Fatal("failed to create directory", "err", err)
-		}
-	} else if err != nil {
-		log.Fatal("failed to check if directory exists", "err", err)
-	}
-	sm, err := generator.New(
-		ctx,
-		*storePath,
-		cfg.General,
-		log,
-		cfg.Sitemaps,
-	)
-	if err != nil {
-		log.Fatal("failed to initialize generator", "err", err)
-	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
 	go func() {
 		sigs := make(chan os.Signal, 1)
@@ -61,7 +57,86 @@ func main() {
 		cancel()
 	}()
 
-	if err := sm.Start(); err != nil {
-		log.Fatal("failed to start sitemap", "err", err)
+	cmd := "run"
+	if len(args) != 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
+	}
+
+	switch cmd {
+	case "run":
+		return runCmd(ctx, log, args)
+	case "generate":
+		return generateCmd(ctx, log, args)
+	case "validate":
+		return validateCmd(ctx, log, args)
+	case "dry-run":
+		return dryRunCmd(ctx, log, args)
+	case "serve":
+		return serveCmd(ctx, log, args)
+	case "check":
+		return checkCmd(log, args)
+	case "help":
+		fmt.Print(usage)
+		return exitOK
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", cmd, usage)
+		return exitUsage
+	}
+}
+
+type commonFlags struct {
+	configPath *string
+	storePath  *string
+	strict     *bool
+}
+
+func newFlagSet(name string, withStore bool) (*flag.FlagSet, *commonFlags) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	f := new(commonFlags)
+	f.configPath = fs.String("config", "./config.json", "path to config file")
+	f.strict = fs.Bool("strict", false, "reject unknown keys in config file")
+	if withStore {
+		f.storePath = fs.String("store", _defaultStoreDir, "path to store sitemap")
+	}
+	return fs, f
+}
+
+// loadConfig load and check config file, every issue logged.
+func loadConfig(log logger.Logger, path string, strict bool) (*config.Config, config.Issues, error) {
+	cfg, err := config.New(path)
+	if err != nil {
+		log.Error("failed to load config", "err", err)
+		return nil, nil, err
+	}
+
+	issues := cfg.Check(strict)
+	for _, w := range issues.Warnings() {
+		log.Warn("config warning", "issue", w.Error())
+	}
+
+	for _, e := range issues.Errors() {
+		log.Error("config error", "issue", e.Error())
+	}
+
+	if err := issues.Err(); err != nil {
+		log.Error("invalid config", "errors", len(issues.Errors()))
+		return nil, issues, err
+	}
+
+	log.Info("configuration file loaded")
+
+	return cfg, issues, nil
+}
+
+func ensureStore(log logger.Logger, storePath string) error {
+	if _, err := os.Stat(storePath); os.IsNotExist(err) {
+		if err := os.Mkdir(storePath, 0o777); err != nil {
+			log.Error("failed to create directory", "err", err)
+			return err
+		}
+	} else if err != nil {
+		log.Error("failed to check if directory exists", "err", err)
+		return err
 	}
+	return nil
 }
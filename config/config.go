@@ -1,7 +1,6 @@
 package config
 
 import (
-	"net/url"
 	"os"
 
 	"gopkg.in/yaml.v3"
@@ -17,84 +16,14 @@ func New(configPath string) (*Config, error) {
 	}()
 
 	cfg := new(Config)
+	cfg.node = new(yaml.Node)
 
-	if err := yaml.NewDecoder(file).Decode(cfg); err != nil {
+	if err := yaml.NewDecoder(file).Decode(cfg.node); err != nil {
 		return nil, err
 	}
-	return cfg, nil
-}
-
-func (c *Config) Validate() error {
-	if c.General == nil {
-		return ErrMissingGeneralConfig
-	}
-
-	if c.General.BaseIndexURL == "" {
-		return ErrInvalidBaseIndexURL
-	}
-
-	_, err := url.Parse(c.General.BaseIndexURL)
-	if err != nil {
-		return ErrInvalidBaseIndexURL
-	}
-
-	if c.General.Stylesheet != "" {
-		switch c.General.Stylesheet {
-		case Style1, Style2:
-		default:
-			c.General.Stylesheet = Style1
-		}
-	}
-
-	if c.General.MeiliSearch == nil {
-		return ErrMissingMeilisearchConfig
-	}
-
-	if c.General.MeiliSearch.Host == "" {
-		return ErrMeilisearchHostRequire
-	}
-
-	for name, sitemap := range c.Sitemaps {
-		if err := validateSitemapConfig(name, sitemap); err != nil {
-			return err
-		}
-	}
 
-	return nil
-}
-
-func validateSitemapConfig(name string, sitemap *SitemapConfig) error {
-	if name == "" {
-		return ErrIndexNameIsEmpty
-	}
-
-	if !sitemap.Sitemap {
-		return ErrInvalidSitemapConfig
-	}
-
-	if sitemap.BaseAddress == "" {
-		return ErrMissingBaseAddressSitemap
-	}
-
-	if sitemap.FieldMap == nil {
-		return ErrInvalidFieldMap
-	}
-
-	if sitemap.FieldMap.UniqueField == "" {
-		return ErrInvalidUniqueField
-	}
-
-	switch sitemap.FieldMap.ChangeFreq {
-	case Always, Hourly, Daily, Weekly, Monthly, Yearly, Never:
-	default:
-		sitemap.FieldMap.ChangeFreq = Daily
-	}
-
-	switch sitemap.FieldMap.Priority {
-	case Low, Medium, High, Highest:
-	default:
-		sitemap.FieldMap.Priority = High
+	if err := cfg.node.Decode(cfg); err != nil {
+		return nil, err
 	}
-
-	return nil
+	return cfg, nil
 }
@@ -1,9 +1,13 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_New(t *testing.T) {
@@ -173,7 +177,688 @@ func TestValidateConfig(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			err := tt.config.Validate()
-			assert.Equal(t, tt.expectErr, err)
+			if tt.expectErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.expectErr)
+		})
+	}
+}
+
+func TestCheckExampleConfig(t *testing.T) {
+	config, err := New("../config.example.yml")
+	require.NoError(t, err)
+
+	issues := config.Check(true)
+	assert.NoError(t, issues.Err())
+}
+
+func TestCheckAggregatedIssues(t *testing.T) {
+	const content = `general:
+  base_index_url: https://example.com
+  stylesheet: fancy
+  meilisearch:
+    host: http://localhost:7700
+    api_key: masterKey
+    timeout: 10
+sitemaps:
+  movies:
+    sitemap: true
+    base_address: https://example.com/movies/
+    field_map:
+      unique_field: title
+      lastmod: created_at
+      changefreq: sometimes
+      priority: urgent
+  series:
+    sitemap: true
+    base_address: /series/
+    field_map:
+      lastmod: created_at
+`
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	config, err := New(path)
+	require.NoError(t, err)
+
+	t.Run("non strict", func(t *testing.T) {
+		issues := config.Check(false)
+
+		require.Len(t, issues.Warnings(), 1)
+		assert.Equal(t, "general.meilisearch.timeout: unknown key (line 7)", issues.Warnings()[0].Error())
+
+		msgs := make([]string, 0)
+		for _, i := range issues.Errors() {
+			msgs = append(msgs, i.Error())
+		}
+
+		assert.Equal(t, []string{
+			`general.stylesheet: unknown value "fancy" (line 3)`,
+			`sitemaps.movies.field_map.changefreq: unknown value "sometimes" (line 15)`,
+			`sitemaps.movies.field_map.priority: unknown value "urgent" (line 16)`,
+			"sitemaps.series.base_address: base_address must be an absolute url (line 19)",
+			"sitemaps.series.field_map.unique_field: invalid or missing unique_field in field_map (line 20)",
+		}, msgs)
+
+		err := issues.Err()
+		var vErr *ValidationError
+		require.ErrorAs(t, err, &vErr)
+		assert.Len(t, vErr.Issues, 5)
+		assert.ErrorIs(t, err, ErrUnknownValue)
+		assert.ErrorIs(t, err, ErrInvalidUniqueField)
+		assert.NotErrorIs(t, err, ErrUnknownKey)
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		issues := config.Check(true)
+
+		assert.Empty(t, issues.Warnings())
+		assert.Len(t, issues.Errors(), 6)
+		assert.ErrorIs(t, issues.Err(), ErrUnknownKey)
+	})
+}
+
+func TestValidateDefaults(t *testing.T) {
+	config := &Config{
+		General: &GeneralConfig{
+			BaseIndexURL: "https://example.com",
+			Serve:        &ServeConfig{},
+			MeiliSearch: &MeiliSearchConfig{
+				Host:   "http://localhost:7700",
+				APIKey: "masterKey",
+			},
+		},
+		Sitemaps: map[string]*SitemapConfig{
+			"movies": {
+				Sitemap:     true,
+				BaseAddress: "https://example.com/movies/",
+				FieldMap: &FieldMapConfig{
+					UniqueField: "title",
+				},
+			},
+		},
+	}
+
+	issues := config.Check(false)
+	require.NoError(t, issues.Err())
+	require.Len(t, issues.Warnings(), 1)
+	assert.Equal(t, "sitemaps.movies.field_map.lastmod", issues.Warnings()[0].Path)
+	assert.Zero(t, issues.Warnings()[0].Line)
+
+	assert.Equal(t, Daily, config.Sitemaps["movies"].FieldMap.ChangeFreq)
+	assert.Equal(t, High, config.Sitemaps["movies"].FieldMap.Priority)
+	assert.Equal(t, DefaultStaleFactor, config.General.Serve.StaleFactor)
+}
+
+func TestValidateRetention(t *testing.T) {
+	tests := []struct {
+		name      string
+		retention *RetentionConfig
+		err       error
+	}{
+		{name: "valid", retention: &RetentionConfig{MaxShrink: 20, GracePeriod: 72 * time.Hour}},
+		{name: "max shrink over 100", retention: &RetentionConfig{MaxShrink: 120}, err: ErrInvalidMaxShrink},
+		{name: "negative grace period", retention: &RetentionConfig{GracePeriod: -time.Hour}, err: ErrNegativeValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				General: &GeneralConfig{
+					BaseIndexURL: "https://example.com",
+					MeiliSearch:  &MeiliSearchConfig{Host: "http://localhost:7700", APIKey: "masterKey"},
+				},
+				Sitemaps: map[string]*SitemapConfig{
+					"movies": {
+						Sitemap:     true,
+						BaseAddress: "https://example.com/movies/",
+						Retention:   tt.retention,
+						FieldMap:    &FieldMapConfig{UniqueField: "id", LastMod: "created_at"},
+					},
+				},
+			}
+
+			err := config.Validate()
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateRetry(t *testing.T) {
+	maxAttempts, jitter := 3, 1.5
+	config := &Config{
+		General: &GeneralConfig{
+			BaseIndexURL: "https://example.com",
+			MeiliSearch: &MeiliSearchConfig{
+				Host:   "http://localhost:7700",
+				APIKey: "masterKey",
+				Retry: &RetryConfig{
+					MaxAttempts: &maxAttempts,
+					Timeout:     -time.Second,
+					Jitter:      &jitter,
+				},
+			},
+		},
+	}
+
+	err := config.Validate()
+	assert.ErrorIs(t, err, ErrNegativeValue)
+	assert.ErrorIs(t, err, ErrInvalidJitter)
+	assert.Len(t, config.Check(false).Errors(), 2)
+}
+
+func TestValidateFetchStrategy(t *testing.T) {
+	tests := []struct {
+		name      string
+		fetch     *IndexFetch
+		strategy  FetchStrategy
+		cursorKey string
+		err       error
+	}{
+		{name: "default", fetch: &IndexFetch{}, strategy: FetchOffset},
+		{name: "cursor of unique field", fetch: &IndexFetch{Strategy: FetchCursor}, strategy: FetchCursor, cursorKey: "id"},
+		{
+			name:      "cursor key",
+			fetch:     &IndexFetch{Strategy: FetchCursor, CursorKey: "slug"},
+			strategy:  FetchCursor,
+			cursorKey: "slug",
+		},
+		{name: "unknown", fetch: &IndexFetch{Strategy: "keyset"}, strategy: "keyset", err: ErrUnknownValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				General: &GeneralConfig{
+					BaseIndexURL: "https://example.com",
+					MeiliSearch:  &MeiliSearchConfig{Host: "http://localhost:7700", APIKey: "masterKey"},
+				},
+				Sitemaps: map[string]*SitemapConfig{
+					"movies": {
+						Sitemap:     true,
+						BaseAddress: "https://example.com/movies/",
+						Fetch:       tt.fetch,
+						FieldMap:    &FieldMapConfig{UniqueField: "id", LastMod: "created_at"},
+					},
+				},
+			}
+
+			err := config.Validate()
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.strategy, tt.fetch.Strategy)
+			assert.Equal(t, tt.cursorKey, tt.fetch.CursorKey)
+		})
+	}
+}
+
+func TestValidateLiveUpdate(t *testing.T) {
+	tests := []struct {
+		name string
+		live *LiveConfig
+		mode LiveMode
+		err  error
+	}{
+		{name: "default mode", live: &LiveConfig{Enabled: true, Interval: 60}, mode: LiveInterval},
+		{name: "interval required", live: &LiveConfig{Enabled: true}, mode: LiveInterval, err: ErrInvalidLiveInterval},
+		{name: "tasks without interval", live: &LiveConfig{Enabled: true, Mode: LiveTasks}, mode: LiveTasks},
+		{
+			name: "negative debounce",
+			live: &LiveConfig{Enabled: true, Mode: LiveTasks, Debounce: -time.Second},
+			mode: LiveTasks,
+			err:  ErrNegativeValue,
+		},
+		{name: "unknown mode", live: &LiveConfig{Enabled: true, Mode: "webhook"}, mode: "webhook", err: ErrUnknownValue},
+		{name: "every duration", live: &LiveConfig{Enabled: true, Every: "90m", Jitter: time.Minute}, mode: LiveInterval},
+		{name: "every changefreq", live: &LiveConfig{Enabled: true, Every: EveryChangeFreq}, mode: LiveInterval},
+		{name: "invalid every", live: &LiveConfig{Enabled: true, Every: "daily"}, mode: LiveInterval, err: ErrInvalidDuration},
+		{
+			name: "cron with timezone",
+			live: &LiveConfig{Enabled: true, Cron: "0 3 * * *", Timezone: "Europe/Berlin", RunAtStart: true},
+			mode: LiveInterval,
+		},
+		{name: "cron descriptor", live: &LiveConfig{Enabled: true, Cron: "@hourly"}, mode: LiveInterval},
+		{name: "invalid cron", live: &LiveConfig{Enabled: true, Cron: "0 3 * *"}, mode: LiveInterval, err: ErrInvalidCron},
+		{
+			name: "invalid timezone",
+			live: &LiveConfig{Enabled: true, Cron: "@daily", Timezone: "Mars/Olympus"},
+			mode: LiveInterval,
+			err:  ErrInvalidTimezone,
+		},
+		{
+			name: "conflicting schedule",
+			live: &LiveConfig{Enabled: true, Interval: 60, Cron: "@daily"},
+			mode: LiveInterval,
+			err:  ErrConflictingSchedule,
+		},
+		{
+			name: "unknown overlap",
+			live: &LiveConfig{Enabled: true, Interval: 60, Overlap: "parallel"},
+			mode: LiveInterval,
+			err:  ErrUnknownValue,
+		},
+		{
+			name: "negative jitter",
+			live: &LiveConfig{Enabled: true, Every: "1h", Jitter: -time.Second},
+			mode: LiveInterval,
+			err:  ErrNegativeValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				General: &GeneralConfig{
+					BaseIndexURL: "https://example.com",
+					MeiliSearch:  &MeiliSearchConfig{Host: "http://localhost:7700", APIKey: "masterKey"},
+				},
+				Sitemaps: map[string]*SitemapConfig{
+					"movies": {
+						Sitemap:     true,
+						BaseAddress: "https://example.com/movies/",
+						LiveUpdate:  tt.live,
+						FieldMap:    &FieldMapConfig{UniqueField: "id", LastMod: "created_at"},
+					},
+				},
+			}
+
+			err := config.Validate()
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.mode, tt.live.Mode)
+		})
+	}
+}
+
+func TestFieldMapFields(t *testing.T) {
+	fm := &FieldMapConfig{
+		UniqueField: "id",
+		LastMod:     "meta.updated_at",
+		Image:       &ImageConfig{Loc: "poster|https://cdn.example.com|.jpg", Title: "title|year"},
+		Video:       &VideoConfig{ContentLoc: "file", Live: "is_live"},
+		News:        &NewsConfig{Keywords: "tags"},
+	}
+
+	assert.Equal(t, []Field{
+		{Path: "unique_field", Key: "id", Kind: FieldUnique, Critical: true},
+		{Path: "lastmod", Key: "meta.updated_at", Kind: FieldDate, Critical: true},
+		{Path: "image.loc", Key: "poster", Kind: FieldScalar},
+		{Path: "image.title", Key: "title", Kind: FieldScalar},
+		{Path: "image.title", Key: "year", Kind: FieldScalar},
+		{Path: "video.content_loc", Key: "file", Kind: FieldString},
+		{Path: "video.live", Key: "is_live", Kind: FieldBool},
+		{Path: "news.keywords", Key: "tags", Kind: FieldArray},
+	}, fm.Fields())
+}
+
+func TestFieldMapAttributes(t *testing.T) {
+	tests := []struct {
+		name     string
+		fieldMap *FieldMapConfig
+		want     []string
+	}{
+		{
+			name:     "unique and lastmod",
+			fieldMap: &FieldMapConfig{UniqueField: "id", LastMod: "meta.updated_at"},
+			want:     []string{"id", "meta"},
+		},
+		{
+			name: "loc prefix and suffix are not attributes",
+			fieldMap: &FieldMapConfig{
+				UniqueField: "id",
+				Image:       &ImageConfig{Loc: "poster|https://cdn.example.com|.jpg", Title: "title|year"},
+			},
+			want: []string{"id", "poster", "title", "year"},
+		},
+		{
+			name: "video and news",
+			fieldMap: &FieldMapConfig{
+				UniqueField: "slug",
+				Video:       &VideoConfig{ThumbnailLoc: "thumb", Title: "title", Live: "is_live"},
+				News: &NewsConfig{
+					Publication: &NewsPublicationConfig{Name: "publisher.name", Language: "publisher.lang"},
+					PubDate:     "published_at",
+					Title:       "title",
+				},
+			},
+			want: []string{"is_live", "published_at", "publisher", "slug", "thumb", "title"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.fieldMap.Attributes())
+		})
+	}
+}
+
+func TestValidateAdmin(t *testing.T) {
+	tests := []struct {
+		name  string
+		admin *AdminConfig
+		err   error
+	}{
+		{name: "disabled", admin: &AdminConfig{}},
+		{name: "enabled with token", admin: &AdminConfig{Enabled: true, Token: "secret"}},
+		{name: "token required", admin: &AdminConfig{Enabled: true}, err: ErrMissingAdminToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				General: &GeneralConfig{
+					BaseIndexURL: "https://example.com",
+					MeiliSearch:  &MeiliSearchConfig{Host: "http://localhost:7700", APIKey: "masterKey"},
+					Serve:        &ServeConfig{Enable: true, Listen: ":8080", Admin: tt.admin},
+				},
+				Sitemaps: map[string]*SitemapConfig{
+					"movies": {
+						Sitemap:     true,
+						BaseAddress: "https://example.com/movies/",
+						FieldMap:    &FieldMapConfig{UniqueField: "id"},
+					},
+				},
+			}
+
+			err := config.Validate()
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRedact(t *testing.T) {
+	config := &Config{
+		General: &GeneralConfig{
+			BaseIndexURL: "https://example.com",
+			MeiliSearch:  &MeiliSearchConfig{Host: "http://localhost:7700", APIKey: "masterKey"},
+			Serve:        &ServeConfig{Enable: true, Admin: &AdminConfig{Enabled: true, Token: "secret"}},
+			Tracing:      &TracingConfig{Headers: map[string]string{"Authorization": "Bearer otel"}},
+		},
+		Sitemaps: map[string]*SitemapConfig{
+			"movies": {Sitemap: true, FieldMap: &FieldMapConfig{UniqueField: "id"}},
+		},
+	}
+
+	m, err := config.Redact()
+	require.NoError(t, err)
+
+	general := m["general"].(map[string]any)
+	assert.Equal(t, "https://example.com", general["base_index_url"])
+	assert.Equal(t, Redacted, general["meilisearch"].(map[string]any)["api_key"])
+	assert.Equal(t, "http://localhost:7700", general["meilisearch"].(map[string]any)["host"])
+	assert.Equal(t, Redacted, general["serve"].(map[string]any)["admin"].(map[string]any)["token"])
+	assert.Equal(t, Redacted, general["tracing"].(map[string]any)["headers"].(map[string]any)["Authorization"])
+	assert.Equal(t, "id", m["sitemaps"].(map[string]any)["movies"].(map[string]any)["field_map"].(map[string]any)["unique_field"])
+
+	// config is not changed.
+	assert.Equal(t, "masterKey", config.General.MeiliSearch.APIKey)
+}
+
+func TestValidateServeSecurity(t *testing.T) {
+	tests := []struct {
+		name  string
+		serve *ServeConfig
+		err   error
+	}{
+		{name: "tls", serve: &ServeConfig{TLS: &TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem"}}},
+		{name: "tls key required", serve: &ServeConfig{TLS: &TLSConfig{CertFile: "cert.pem"}}, err: ErrMissingTLSFile},
+		{name: "basic", serve: &ServeConfig{Auth: &AuthConfig{Type: AuthBasic, Username: "admin", Password: "pass"}}},
+		{
+			name:  "basic password required",
+			serve: &ServeConfig{Auth: &AuthConfig{Type: AuthBasic, Username: "admin"}},
+			err:   ErrMissingAuthCredentials,
+		},
+		{name: "bearer token required", serve: &ServeConfig{Auth: &AuthConfig{Type: AuthBearer}}, err: ErrMissingAuthCredentials},
+		{name: "unknown auth", serve: &ServeConfig{Auth: &AuthConfig{Type: "digest"}}, err: ErrUnknownValue},
+		{name: "allow cidrs", serve: &ServeConfig{AllowCIDRs: []string{"10.0.0.0/8", "::1", "fd00::/8"}}},
+		{name: "invalid cidr", serve: &ServeConfig{AllowCIDRs: []string{"10.0.0.0/33"}}, err: ErrInvalidCIDR},
+		{
+			name:  "admin without token by serve auth",
+			serve: &ServeConfig{Admin: &AdminConfig{Enabled: true}, Auth: &AuthConfig{Type: AuthBearer, Token: "secret"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.serve.Enable = true
+			tt.serve.Listen = ":8080"
+
+			config := &Config{
+				General: &GeneralConfig{
+					BaseIndexURL: "https://example.com",
+					MeiliSearch:  &MeiliSearchConfig{Host: "http://localhost:7700", APIKey: "masterKey"},
+					Serve:        tt.serve,
+				},
+				Sitemaps: map[string]*SitemapConfig{
+					"movies": {
+						Sitemap:     true,
+						BaseAddress: "https://example.com/movies/",
+						FieldMap:    &FieldMapConfig{UniqueField: "id"},
+					},
+				},
+			}
+
+			err := config.Validate()
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateStylesheet(t *testing.T) {
+	tests := []struct {
+		name       string
+		stylesheet Stylesheet
+		index      Stylesheet
+		feed       Stylesheet
+		want       Stylesheet // index and feed stylesheet
+		err        error
+	}{
+		{name: "none"},
+		{name: "bundled", stylesheet: Style1, want: Style1},
+		{name: "url", stylesheet: "https://cdn.example.com/sitemap.xsl"},
+		{name: "path", stylesheet: "./assets/sitemap.xsl"},
+		{
+			name:       "path with index and feed",
+			stylesheet: "./assets/sitemap.xsl",
+			index:      "./assets/index.xsl",
+			feed:       "./assets/index.xsl",
+			want:       "./assets/index.xsl",
+		},
+		{name: "unknown", stylesheet: "style3", err: ErrUnknownValue},
+		{name: "not xsl", stylesheet: "./assets/sitemap.css", err: ErrUnknownValue},
+		{name: "unknown index stylesheet", index: "style3", err: ErrUnknownValue},
+		{name: "unknown feed stylesheet", feed: "style3", err: ErrUnknownValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				General: &GeneralConfig{
+					BaseIndexURL:    "https://example.com",
+					MeiliSearch:     &MeiliSearchConfig{Host: "http://localhost:7700", APIKey: "masterKey"},
+					Stylesheet:      tt.stylesheet,
+					IndexStylesheet: tt.index,
+					FeedStylesheet:  tt.feed,
+				},
+				Sitemaps: map[string]*SitemapConfig{
+					"movies": {
+						Sitemap:     true,
+						BaseAddress: "https://example.com/movies/",
+						FieldMap:    &FieldMapConfig{UniqueField: "id"},
+					},
+				},
+			}
+
+			err := config.Validate()
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, config.General.IndexStylesheet)
+			assert.Equal(t, tt.want, config.General.FeedStylesheet)
+		})
+	}
+}
+
+func TestValidateNewsSitemap(t *testing.T) {
+	news := &NewsConfig{
+		Publication: &NewsPublicationConfig{Name: "publisher", Language: "lang"},
+		PubDate:     "published_at",
+		Title:       "title",
+	}
+
+	tests := []struct {
+		name    string
+		sitemap *SitemapConfig
+		err     error
+		live    *LiveConfig
+	}{
+		{
+			name:    "default live update",
+			sitemap: &SitemapConfig{FieldMap: &FieldMapConfig{UniqueField: "id", News: news}},
+			live: &LiveConfig{
+				Enabled: true, Every: DefaultNewsEvery, RunAtStart: true, Mode: LiveInterval, Overlap: OverlapSkip,
+			},
+		},
+		{
+			name: "live update kept",
+			sitemap: &SitemapConfig{
+				LiveUpdate: &LiveConfig{Enabled: true, Cron: "*/5 * * * *"},
+				FieldMap:   &FieldMapConfig{UniqueField: "id", News: news},
+			},
+			live: &LiveConfig{Enabled: true, Cron: "*/5 * * * *", Mode: LiveInterval, Overlap: OverlapSkip},
+		},
+		{
+			name: "retention dropped",
+			sitemap: &SitemapConfig{
+				Retention: &RetentionConfig{GracePeriod: time.Hour},
+				FieldMap:  &FieldMapConfig{UniqueField: "id", News: news},
+			},
+			live: &LiveConfig{
+				Enabled: true, Every: DefaultNewsEvery, RunAtStart: true, Mode: LiveInterval, Overlap: OverlapSkip,
+			},
+		},
+		{
+			name:    "news field map required",
+			sitemap: &SitemapConfig{FieldMap: &FieldMapConfig{UniqueField: "id"}},
+			err:     ErrMissingNewsFieldMap,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.sitemap.Sitemap = true
+			tt.sitemap.NewsSitemap = true
+			tt.sitemap.BaseAddress = "https://example.com/news/"
+
+			config := &Config{
+				General: &GeneralConfig{
+					BaseIndexURL: "https://example.com",
+					MeiliSearch:  &MeiliSearchConfig{Host: "http://localhost:7700", APIKey: "masterKey"},
+				},
+				Sitemaps: map[string]*SitemapConfig{"news": tt.sitemap},
+			}
+
+			err := config.Validate()
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.live, tt.sitemap.LiveUpdate)
+			assert.Nil(t, tt.sitemap.Retention)
+		})
+	}
+}
+
+func TestValidateFeed(t *testing.T) {
+	tests := []struct {
+		name string
+		rss  bool
+		feed *FeedConfig
+		want *FeedConfig
+		err  error
+	}{
+		{
+			name: "rss shorthand",
+			rss:  true,
+			want: &FeedConfig{
+				Formats: []FeedFormat{FeedRSS}, Title: "movies", Link: "https://example.com/movies/",
+				MaxItems: DefaultFeedMaxItems,
+			},
+		},
+		{
+			name: "default format",
+			feed: &FeedConfig{Title: "Movies", MaxItems: 10},
+			want: &FeedConfig{
+				Formats: []FeedFormat{FeedRSS}, Title: "Movies", Link: "https://example.com/movies/", MaxItems: 10,
+			},
+		},
+		{
+			name: "rss added to formats",
+			rss:  true,
+			feed: &FeedConfig{Formats: []FeedFormat{FeedAtom, FeedJSON}, Link: "https://example.com"},
+			want: &FeedConfig{
+				Formats: []FeedFormat{FeedAtom, FeedJSON, FeedRSS}, Title: "movies", Link: "https://example.com",
+				MaxItems: DefaultFeedMaxItems,
+			},
+		},
+		{name: "unknown format", feed: &FeedConfig{Formats: []FeedFormat{"yaml"}}, err: ErrUnknownValue},
+		{name: "invalid icon", feed: &FeedConfig{Icon: "logo.png"}, err: ErrInvalidFeedIcon},
+		{name: "negative max items", feed: &FeedConfig{MaxItems: -1}, err: ErrNegativeValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := &SitemapConfig{
+				Sitemap:     true,
+				RSS:         tt.rss,
+				Feed:        tt.feed,
+				BaseAddress: "https://example.com/movies/",
+				FieldMap:    &FieldMapConfig{UniqueField: "id", Feed: &FeedItemConfig{Title: "title"}},
+			}
+
+			config := &Config{
+				General: &GeneralConfig{
+					BaseIndexURL: "https://example.com",
+					MeiliSearch:  &MeiliSearchConfig{Host: "http://localhost:7700", APIKey: "masterKey"},
+				},
+				Sitemaps: map[string]*SitemapConfig{"movies": sm},
+			}
+
+			err := config.Validate()
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, sm.Feed)
 		})
 	}
 }
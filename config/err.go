@@ -5,11 +5,32 @@ import "errors"
 var (
 	ErrMissingMeilisearchConfig  = errors.New("meilisearch configuration is missing")
 	ErrMeilisearchHostRequire    = errors.New("meilisearch host is required")
+	ErrInvalidMeilisearchHost    = errors.New("invalid meilisearch host")
 	ErrInvalidBaseIndexURL       = errors.New("invalid or missing base_index_url")
 	ErrInvalidSitemapConfig      = errors.New("sitemap is required")
 	ErrMissingBaseAddressSitemap = errors.New("base_address sitemap is required")
+	ErrInvalidBaseAddress        = errors.New("base_address must be an absolute url")
 	ErrInvalidFieldMap           = errors.New("invalid or missing field_map in sitemap config")
 	ErrInvalidUniqueField        = errors.New("invalid or missing unique_field in field_map")
 	ErrIndexNameIsEmpty          = errors.New("index name is empty")
 	ErrMissingGeneralConfig      = errors.New("general config is missing")
+	ErrMissingServeListen        = errors.New("serve listen address is required")
+	ErrInvalidLiveInterval       = errors.New("live_update interval must be greater than zero")
+	ErrConflictingSchedule       = errors.New("only one of interval, every or cron can be set")
+	ErrInvalidDuration           = errors.New("invalid duration")
+	ErrInvalidCron               = errors.New("invalid cron expression")
+	ErrInvalidTimezone           = errors.New("invalid timezone")
+	ErrInvalidStaleFactor        = errors.New("stale_factor must be greater than zero")
+	ErrMissingAdminToken         = errors.New("admin token is required")
+	ErrMissingTLSFile            = errors.New("tls cert_file and key_file are required")
+	ErrMissingAuthCredentials    = errors.New("auth credentials are required")
+	ErrInvalidCIDR               = errors.New("invalid cidr or ip address")
+	ErrNegativeValue             = errors.New("must not be negative")
+	ErrInvalidJitter             = errors.New("jitter must be between 0 and 1")
+	ErrInvalidMaxShrink          = errors.New("max_shrink must be between 0 and 100")
+	ErrMissingImageLoc           = errors.New("image loc is required")
+	ErrMissingNewsFieldMap       = errors.New("field_map news is required by news sitemap")
+	ErrInvalidFeedIcon           = errors.New("feed icon must be an absolute url")
+	ErrUnknownValue              = errors.New("unknown value")
+	ErrUnknownKey                = errors.New("unknown key")
 )
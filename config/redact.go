@@ -0,0 +1,56 @@
+package config
+
+import "gopkg.in/yaml.v3"
+
+// Redacted is value of secrets in redacted config.
+const Redacted = "REDACTED"
+
+// secretKeys are keys of config which values are secret, values of headers are redacted too.
+var secretKeys = map[string]bool{
+	"api_key":  true,
+	"token":    true,
+	"password": true,
+	"headers":  true,
+}
+
+// Redact returns config as map by yaml keys with values of secrets replaced by Redacted.
+func (c *Config) Redact() (map[string]any, error) {
+	b, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]any)
+	if err := yaml.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+
+	redact(m)
+
+	return m, nil
+}
+
+func redact(m map[string]any) {
+	for k, v := range m {
+		switch val := v.(type) {
+		case map[string]any:
+			if secretKeys[k] {
+				for hk := range val {
+					val[hk] = Redacted
+				}
+				continue
+			}
+			redact(val)
+		case []any:
+			for _, item := range val {
+				if im, ok := item.(map[string]any); ok {
+					redact(im)
+				}
+			}
+		default:
+			if secretKeys[k] && v != "" && v != nil {
+				m[k] = Redacted
+			}
+		}
+	}
+}
@@ -1,10 +1,19 @@
 package config
 
-import "time"
+import (
+	"net/netip"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
 
 type Config struct {
 	General  *GeneralConfig            `yaml:"general"`
 	Sitemaps map[string]*SitemapConfig `yaml:"sitemaps"`
+
+	node *yaml.Node // node is parsed config file, used for line of issues
 }
 
 type GeneralConfig struct {
@@ -12,20 +21,94 @@ type GeneralConfig struct {
 	IndexSitemapPath string             `yaml:"indexsitemap_path"`
 	FileName         string             `yaml:"file_name"`
 	Prefix           string             `yaml:"prefix"`
-	Stylesheet       Stylesheet         `yaml:"stylesheet"`
+	Stylesheet       Stylesheet         `yaml:"stylesheet"`       // style1, style2, url or path of xsl file
+	IndexStylesheet  Stylesheet         `yaml:"index_stylesheet"` // stylesheet of sitemap index, default is bundled stylesheet
+	FeedStylesheet   Stylesheet         `yaml:"feed_stylesheet"`  // stylesheet of rss and atom feeds, default is bundled stylesheet
+	ValidateOutput   ValidateMode       `yaml:"validate_output"`
 	Serve            *ServeConfig       `yaml:"serve"`
 	MeiliSearch      *MeiliSearchConfig `yaml:"meilisearch"`
+	Tracing          *TracingConfig     `yaml:"tracing"`
+	Fetch            *FetchConfig       `yaml:"fetch"`
+	Robots           *RobotsConfig      `yaml:"robots"`
+	TxtIndex         bool               `yaml:"txt_index"`  // text index of txt sitemaps, named file_name with .txt
+	StatePath        string             `yaml:"state_path"` // directory of urls of previous runs, default is store path with .state suffix
+}
+
+// RobotsConfig of robots.txt written to store with Sitemap lines of root sitemap index.
+type RobotsConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Template is file of rules written before Sitemap lines, default allow all user agents.
+	Template string `yaml:"template"`
+}
+
+// TracingConfig of OpenTelemetry traces exported with OTLP over HTTP, endpoint is host:port
+// or url, empty endpoint use OTEL_EXPORTER_OTLP_* environment variables or localhost:4318.
+type TracingConfig struct {
+	Enabled     bool              `yaml:"enabled"`
+	Endpoint    string            `yaml:"endpoint"`
+	Insecure    bool              `yaml:"insecure"`
+	Headers     map[string]string `yaml:"headers"`
+	ServiceName string            `yaml:"service_name"`
 }
 
 type ServeConfig struct {
-	Enable bool   `yaml:"enable"`
-	Listen string `yaml:"listen"`
-	PPROF  bool   `yaml:"pprof"`
+	Enable  bool   `yaml:"enable"`
+	Listen  string `yaml:"listen"`
+	PPROF   bool   `yaml:"pprof"`
+	Metrics bool   `yaml:"metrics"`
+	// StaleFactor mark live sitemap not ready when StaleFactor runs of schedule are missed, default 3.
+	StaleFactor int          `yaml:"stale_factor"`
+	Admin       *AdminConfig `yaml:"admin"`
+	TLS         *TLSConfig   `yaml:"tls"`
+	// AdminListen serve admin, debug and metrics routes on a separate address, default Listen.
+	AdminListen string `yaml:"admin_listen"`
+	// Auth and AllowCIDRs protect admin, debug and metrics routes, sitemaps and health checks are public.
+	Auth       *AuthConfig `yaml:"auth"`
+	AllowCIDRs []string    `yaml:"allow_cidrs"` // client networks or addresses allowed, default all
+}
+
+// TLSConfig of server, certificate is reloaded when files change.
+type TLSConfig struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+type AuthConfig struct {
+	Type     AuthType `yaml:"type"`
+	Username string   `yaml:"username"` // basic auth
+	Password string   `yaml:"password"` // basic auth
+	Token    string   `yaml:"token"`    // bearer auth
+}
+
+type AuthType string
+
+const (
+	AuthBasic  AuthType = "basic"
+	AuthBearer AuthType = "bearer"
+)
+
+// AdminConfig of JSON admin API served on /api/admin/, disabled by default.
+type AdminConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Token   string `yaml:"token"` // bearer token of requests, required when enabled without serve auth
 }
 
 type MeiliSearchConfig struct {
-	Host   string `yaml:"host"`
-	APIKey string `yaml:"api_key"`
+	Host   string       `yaml:"host"`
+	APIKey string       `yaml:"api_key"`
+	Retry  *RetryConfig `yaml:"retry"`
+}
+
+// RetryConfig of requests to Meilisearch, zero values use defaults.
+type RetryConfig struct {
+	MaxAttempts      *int          `yaml:"max_attempts"`      // default 5, 0 retry until run is canceled
+	StartupAttempts  int           `yaml:"startup_attempts"`  // health checks on startup, default 0 wait until reachable
+	InitialBackoff   time.Duration `yaml:"initial_backoff"`   // default 500ms, doubled every attempt
+	MaxBackoff       time.Duration `yaml:"max_backoff"`       // default 30s
+	Jitter           *float64      `yaml:"jitter"`            // random fraction of backoff, default 0.2, 0 disable it
+	Timeout          time.Duration `yaml:"timeout"`           // timeout of every request, default 30s
+	BreakerThreshold int           `yaml:"breaker_threshold"` // consecutive failures open breaker, default 5
+	BreakerCooldown  time.Duration `yaml:"breaker_cooldown"`  // default 30s
 }
 
 type PprofConfig struct {
@@ -34,30 +117,113 @@ type PprofConfig struct {
 }
 
 type SitemapConfig struct {
-	Sitemap         bool            `yaml:"sitemap"`
-	HTMLSitemap     bool            `yaml:"html_sitemap"`
-	RSS             bool            `yaml:"rss"`
-	Filter          string          `yaml:"filter"`
-	BaseAddress     string          `yaml:"base_address"`
-	Compress        bool            `yaml:"compress"`
-	SitemapFileName string          `yaml:"sitemap_file_name"`
-	LiveUpdate      *LiveConfig     `yaml:"live_update"`
-	FieldMap        *FieldMapConfig `yaml:"field_map"`
+	Sitemap         bool             `yaml:"sitemap"`
+	HTMLSitemap     bool             `yaml:"html_sitemap"`
+	RSS             bool             `yaml:"rss"`          // shorthand of rss format of feed
+	Txt             bool             `yaml:"txt"`          // text sitemap of urls, one url per line
+	NewsSitemap     bool             `yaml:"news_sitemap"` // google news sitemap of articles of last 48 hours, requires field_map.news
+	Filter          string           `yaml:"filter"`
+	BaseAddress     string           `yaml:"base_address"`
+	Compress        bool             `yaml:"compress"`
+	SitemapFileName string           `yaml:"sitemap_file_name"`
+	LiveUpdate      *LiveConfig      `yaml:"live_update"`
+	Fetch           *IndexFetch      `yaml:"fetch"`
+	Snapshot        *SnapshotConfig  `yaml:"snapshot"`
+	Retention       *RetentionConfig `yaml:"retention"`
+	Feed            *FeedConfig      `yaml:"feed"`
+	FieldMap        *FieldMapConfig  `yaml:"field_map"`
+}
+
+// FeedConfig of feeds of index written next to sitemap, items are urls of documents mapped by
+// field_map.feed and updated by lastmod.
+type FeedConfig struct {
+	Formats     []FeedFormat `yaml:"formats"` // rss, atom and json, default rss
+	Title       string       `yaml:"title"`   // default index name
+	Description string       `yaml:"description"`
+	Link        string       `yaml:"link"` // home page of feed, default base_address
+	Language    string       `yaml:"language"`
+	Author      string       `yaml:"author"`
+	Icon        string       `yaml:"icon"`      // url of feed image
+	MaxItems    int          `yaml:"max_items"` // newest items of feed, default 100
+}
+
+// RetentionConfig protect sitemap from losing urls of deleted documents or a transient empty fetch.
+type RetentionConfig struct {
+	// MaxShrink refuse to publish sitemap with fewer urls than previous run by more than
+	// this percent, zero disable the check.
+	MaxShrink float64 `yaml:"max_shrink"`
+	// GracePeriod keep urls of removed documents in sitemap, zero remove them at once.
+	GracePeriod time.Duration `yaml:"grace_period"`
+}
+
+// SnapshotConfig make documents of a run consistent while index is written.
+type SnapshotConfig struct {
+	// WaitTasks wait for enqueued and processing tasks of index before fetching.
+	WaitTasks bool          `yaml:"wait_tasks"`
+	Timeout   time.Duration `yaml:"timeout"` // timeout of waiting for tasks, default 5m
+	// Index is fetched instead of sitemap index, for example the live index of swapped indexes.
+	Index string `yaml:"index"`
+}
+
+// FetchConfig of fetching documents pages, zero values use defaults.
+type FetchConfig struct {
+	PageSize         int     `yaml:"page_size"`         // documents per page, default 100
+	Concurrency      int     `yaml:"concurrency"`       // concurrent page requests of all indexes, default 8
+	IndexConcurrency int     `yaml:"index_concurrency"` // concurrent page requests of every index, default 4
+	RateLimit        float64 `yaml:"rate_limit"`        // page requests per second of all indexes, zero is unlimited
+}
+
+// IndexFetch override fetch config for a single index.
+type IndexFetch struct {
+	PageSize    int `yaml:"page_size"`
+	Concurrency int `yaml:"concurrency"`
+	// Fields are attributes of documents fetched, empty fetch attributes referenced
+	// by field map and "*" fetch whole documents.
+	Fields []string `yaml:"fields"`
+	// Strategy of walking index, offset (default) fetch pages by offset in parallel,
+	// cursor fetch pages sorted by CursorKey one after another.
+	Strategy FetchStrategy `yaml:"strategy"`
+	// CursorKey must be unique, filterable and sortable attribute, default unique_field.
+	CursorKey string `yaml:"cursor_key"`
 }
 
 type LiveConfig struct {
 	Enabled  bool  `yaml:"enabled"`
-	Interval int64 `yaml:"interval"`
+	Interval int64 `yaml:"interval"` // interval in seconds
+	// Every is interval as Go duration like "90m", "changefreq" use interval of changefreq of field map.
+	Every string `yaml:"every"`
+	// Cron expression like "0 3 * * *" or descriptor like "@daily", times are in Timezone.
+	Cron       string        `yaml:"cron"`
+	Timezone   string        `yaml:"timezone"`     // IANA timezone of cron, default local
+	Jitter     time.Duration `yaml:"jitter"`       // random delay up to jitter added to every run
+	RunAtStart bool          `yaml:"run_at_start"` // generate at start instead of waiting for first run
+	Overlap    Overlap       `yaml:"overlap"`      // run due while previous run is running, skip or queue, default queue for tasks mode
+	// Mode interval (default) regenerate sitemap every interval, tasks regenerate it when
+	// document tasks of index succeeded.
+	Mode         LiveMode      `yaml:"mode"`
+	PollInterval time.Duration `yaml:"poll_interval"` // poll interval of tasks, default 5s
+	Debounce     time.Duration `yaml:"debounce"`      // quiet time after last task, default 10s
+	MaxDelay     time.Duration `yaml:"max_delay"`     // max delay of regenerating after first task, default 1m
 }
 
 type FieldMapConfig struct {
-	UniqueField string       `yaml:"unique_field"`
-	LastMod     string       `yaml:"lastmod"`
-	ChangeFreq  ChangeFreq   `yaml:"changefreq"`
-	Priority    Priority     `yaml:"priority"`
-	Video       *VideoConfig `yaml:"video,omitempty"`
-	Image       *ImageConfig `yaml:"image,omitempty"`
-	News        *NewsConfig  `yaml:"news,omitempty"`
+	UniqueField string          `yaml:"unique_field"`
+	LastMod     string          `yaml:"lastmod"`
+	ChangeFreq  ChangeFreq      `yaml:"changefreq"`
+	Priority    Priority        `yaml:"priority"`
+	Video       *VideoConfig    `yaml:"video,omitempty"`
+	Image       *ImageConfig    `yaml:"image,omitempty"`
+	News        *NewsConfig     `yaml:"news,omitempty"`
+	Feed        *FeedItemConfig `yaml:"feed,omitempty"`
+}
+
+// FeedItemConfig map documents to feed items, images and videos of field map are enclosures.
+type FeedItemConfig struct {
+	Title      string `yaml:"title"`
+	Summary    string `yaml:"summary"`
+	Content    string `yaml:"content"` // html content of item
+	Author     string `yaml:"author"`
+	Categories string `yaml:"categories"` // string or array of strings
 }
 
 type VideoConfig struct {
@@ -101,9 +267,13 @@ type NewsPublicationConfig struct {
 }
 
 type (
-	ChangeFreq string
-	Priority   string
-	Stylesheet string
+	ChangeFreq    string
+	Priority      string
+	Stylesheet    string
+	ValidateMode  string
+	FetchStrategy string
+	LiveMode      string
+	FeedFormat    string
 )
 
 const (
@@ -128,6 +298,65 @@ const (
 	Style2 Stylesheet = "style2"
 )
 
+// DefaultStaleFactor of serve config.
+const DefaultStaleFactor = 3
+
+const (
+	ValidateOff   ValidateMode = "off"
+	ValidateWarn  ValidateMode = "warn"
+	ValidateError ValidateMode = "error"
+)
+
+const (
+	FetchOffset FetchStrategy = "offset"
+	FetchCursor FetchStrategy = "cursor"
+)
+
+const (
+	LiveInterval LiveMode = "interval"
+	LiveTasks    LiveMode = "tasks"
+)
+
+const (
+	FeedRSS  FeedFormat = "rss"
+	FeedAtom FeedFormat = "atom"
+	FeedJSON FeedFormat = "json"
+)
+
+// DefaultFeedMaxItems of feed config.
+const DefaultFeedMaxItems = 100
+
+// Overlap is policy of a run triggered while previous run of job is running.
+type Overlap string
+
+const (
+	OverlapSkip  Overlap = "skip"  // skip run, default
+	OverlapQueue Overlap = "queue" // run once more after previous run finished
+)
+
+// EveryChangeFreq of live update derive interval from changefreq of field map.
+const EveryChangeFreq = "changefreq"
+
+// limits of google news sitemap, articles published before NewsWindow are dropped.
+const (
+	NewsWindow  = 48 * time.Hour
+	NewsMaxURLs = 1000
+	// DefaultNewsEvery is live update interval of news sitemap without live_update.
+	DefaultNewsEvery = "15m"
+)
+
+// ParseCIDR parse network like 10.0.0.0/8, a single address is network of that address only.
+func ParseCIDR(s string) (netip.Prefix, error) {
+	if !strings.Contains(s, "/") {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	return netip.ParsePrefix(s)
+}
+
 func (c ChangeFreq) Interval() time.Duration {
 	switch c {
 	case Always:
@@ -139,15 +368,14 @@ func (c ChangeFreq) Interval() time.Duration {
 	}
 }
 
-func (s Stylesheet) Link() string {
-	switch s {
-	case Style1:
-		return "https://raw.githubusercontent.com/Ja7ad/meilisitemap/main/stylesheets/style1.xsl"
-	case Style2:
-		return "https://raw.githubusercontent.com/Ja7ad/meilisitemap/main/stylesheets/style2.xsl"
-	default:
-		return ""
-	}
+// Bundled reports stylesheet is bundled in binary and written to store.
+func (s Stylesheet) Bundled() bool {
+	return s == Style1 || s == Style2
+}
+
+// IsURL reports stylesheet is url linked as is, otherwise it's bundled or path of file.
+func (s Stylesheet) IsURL() bool {
+	return isAbsoluteURL(string(s))
 }
 
 func (p Priority) Rate() float64 {
@@ -164,3 +392,132 @@ func (p Priority) Rate() float64 {
 		return 0.8
 	}
 }
+
+// SourceIndex returns index documents of sitemap are fetched from.
+func (sm *SitemapConfig) SourceIndex(name string) string {
+	if sm.Snapshot != nil && sm.Snapshot.Index != "" {
+		return sm.Snapshot.Index
+	}
+	return name
+}
+
+// FieldKind is type of value read by a field map key from documents.
+type FieldKind uint8
+
+const (
+	FieldUnique FieldKind = iota // string or integer number
+	FieldString                  // plain string value, for example direct link of file
+	FieldScalar                  // any value can format as string
+	FieldDate                    // RFC3339 string or unix timestamp
+	FieldBool
+	FieldArray // array of strings
+	FieldList  // string or array of strings
+)
+
+// Field is a document key used by field map.
+type Field struct {
+	Path string // Path of key in field map, like "image.loc"
+	Key  string // Key of document, nested keys separated by dot
+	Kind FieldKind
+	// Critical field drops url of document if value is not usable.
+	Critical bool
+}
+
+// Fields returns every document key used by field map. Loc keys are "key|prefix|suffix"
+// where only first part is a document key, string keys are "key1|key2" and all parts are
+// document keys with values joined by space.
+func (fm *FieldMapConfig) Fields() []Field {
+	fields := make([]Field, 0)
+
+	add := func(path, key string, kind FieldKind, critical bool) {
+		if key == "" {
+			return
+		}
+		fields = append(fields, Field{Path: path, Key: key, Kind: kind, Critical: critical})
+	}
+
+	loc := func(path, key string) {
+		if actual, _, ok := strings.Cut(key, "|"); ok {
+			add(path, actual, FieldScalar, false)
+			return
+		}
+		add(path, key, FieldString, false)
+	}
+
+	str := func(path, key string) {
+		if key == "" {
+			return
+		}
+		for _, k := range strings.Split(key, "|") {
+			add(path, k, FieldScalar, false)
+		}
+	}
+
+	add("unique_field", fm.UniqueField, FieldUnique, true)
+	add("lastmod", fm.LastMod, FieldDate, true)
+
+	if img := fm.Image; img != nil {
+		loc("image.loc", img.Loc)
+		str("image.caption", img.Caption)
+		str("image.title", img.Title)
+		str("image.license", img.License)
+		str("image.geo_location", img.GeoLocation)
+	}
+
+	if vid := fm.Video; vid != nil {
+		loc("video.thumbnail_loc", vid.ThumbnailLoc)
+		loc("video.content_loc", vid.ContentLoc)
+		str("video.title", vid.Title)
+		str("video.description", vid.Description)
+		str("video.player_loc", vid.PlayerLoc)
+		add("video.player_auto_play", vid.PlayerAutoPlay, FieldBool, false)
+		str("video.duration", vid.Duration)
+		add("video.expiration_date", vid.ExpirationDate, FieldDate, false)
+		str("video.rating", vid.Rating)
+		str("video.view_count", vid.ViewCount)
+		add("video.publication_date", vid.PublicationDate, FieldDate, false)
+		add("video.family_friendly", vid.FamilyFriendly, FieldBool, false)
+		str("video.relationship", vid.RestrictionRelationship)
+		str("video.restriction", vid.Restriction)
+		str("video.requires_subscription", vid.RequiresSubscription)
+		add("video.live", vid.Live, FieldBool, false)
+	}
+
+	if news := fm.News; news != nil {
+		if news.Publication != nil {
+			str("news.publication.name", news.Publication.Name)
+			str("news.publication.language", news.Publication.Language)
+		}
+		add("news.pub_date", news.PubDate, FieldDate, false)
+		str("news.title", news.Title)
+		add("news.keywords", news.Keywords, FieldArray, false)
+		str("news.description", news.Description)
+	}
+
+	if feed := fm.Feed; feed != nil {
+		str("feed.title", feed.Title)
+		str("feed.summary", feed.Summary)
+		str("feed.content", feed.Content)
+		str("feed.author", feed.Author)
+		add("feed.categories", feed.Categories, FieldList, false)
+	}
+
+	return fields
+}
+
+// Attributes returns sorted top-level document attributes referenced by field map.
+func (fm *FieldMapConfig) Attributes() []string {
+	seen := make(map[string]struct{})
+	for _, f := range fm.Fields() {
+		attr, _, _ := strings.Cut(f.Key, ".")
+		seen[attr] = struct{}{}
+	}
+
+	attrs := make([]string, 0, len(seen))
+	for attr := range seen {
+		attrs = append(attrs, attr)
+	}
+	sort.Strings(attrs)
+
+	return attrs
+}
@@ -0,0 +1,735 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"gopkg.in/yaml.v3"
+)
+
+type Severity uint8
+
+const (
+	SeverityWarning Severity = iota
+	SeverityError
+)
+
+// Issue is a single problem found in config, addressed by its yaml path
+// for example "sitemaps.movies.field_map.priority".
+type Issue struct {
+	Path     string
+	Line     int // Line in config file, zero if config not loaded from file
+	Severity Severity
+	Err      error
+}
+
+type Issues []*Issue
+
+// ValidationError aggregate all error issues of config.
+type ValidationError struct {
+	Issues Issues
+}
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return ""
+	}
+}
+
+func (i *Issue) Error() string {
+	msg := i.Err.Error()
+	if i.Path != "" {
+		msg = i.Path + ": " + msg
+	}
+
+	if i.Line > 0 {
+		msg = fmt.Sprintf("%s (line %d)", msg, i.Line)
+	}
+
+	return msg
+}
+
+func (i *Issue) Unwrap() error {
+	return i.Err
+}
+
+// Errors returns issues with error severity.
+func (is Issues) Errors() Issues {
+	return is.filter(SeverityError)
+}
+
+// Warnings returns issues with warning severity.
+func (is Issues) Warnings() Issues {
+	return is.filter(SeverityWarning)
+}
+
+// Err returns *ValidationError if issues contains any error, otherwise nil.
+func (is Issues) Err() error {
+	errs := is.Errors()
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Issues: errs}
+}
+
+func (is Issues) filter(severity Severity) Issues {
+	res := make(Issues, 0, len(is))
+	for _, i := range is {
+		if i.Severity == severity {
+			res = append(res, i)
+		}
+	}
+	return res
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Issues))
+	for _, i := range e.Issues {
+		msgs = append(msgs, i.Error())
+	}
+	return fmt.Sprintf("invalid config, %d error(s): %s", len(e.Issues), strings.Join(msgs, "; "))
+}
+
+// Unwrap allow errors.Is and errors.As match sentinel errors of every issue.
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Issues))
+	for _, i := range e.Issues {
+		errs = append(errs, i)
+	}
+	return errs
+}
+
+// Validate checks config and set defaults for empty optional values,
+// it returns *ValidationError contains every error found in config.
+func (c *Config) Validate() error {
+	return c.Check(false).Err()
+}
+
+// Check collects all problems of config, warnings and errors.
+// In strict mode unknown keys in config file reported as error instead of warning.
+func (c *Config) Check(strict bool) Issues {
+	v := &validator{cfg: c}
+
+	if c.node != nil {
+		sev := SeverityWarning
+		if strict {
+			sev = SeverityError
+		}
+		v.unknownKeys(c.node, reflect.TypeOf(c), nil, sev)
+	}
+
+	v.general()
+	v.sitemaps()
+
+	return v.issues
+}
+
+type validator struct {
+	cfg    *Config
+	issues Issues
+}
+
+func (v *validator) add(sev Severity, err error, path ...string) {
+	v.issues = append(v.issues, &Issue{
+		Path:     strings.Join(path, "."),
+		Line:     v.line(path...),
+		Severity: sev,
+		Err:      err,
+	})
+}
+
+func (v *validator) errorf(err error, path ...string) {
+	v.add(SeverityError, err, path...)
+}
+
+func (v *validator) warnf(err error, path ...string) {
+	v.add(SeverityWarning, err, path...)
+}
+
+func (v *validator) general() {
+	g := v.cfg.General
+	if g == nil {
+		v.errorf(ErrMissingGeneralConfig, "general")
+		return
+	}
+
+	if !isAbsoluteURL(g.BaseIndexURL) {
+		v.errorf(ErrInvalidBaseIndexURL, "general", "base_index_url")
+	}
+
+	// only bundled stylesheets render sitemap index and feeds too.
+	if v.stylesheet(g.Stylesheet, "general", "stylesheet") && g.Stylesheet.Bundled() {
+		if g.IndexStylesheet == "" {
+			g.IndexStylesheet = g.Stylesheet
+		}
+		if g.FeedStylesheet == "" {
+			g.FeedStylesheet = g.Stylesheet
+		}
+	}
+	v.stylesheet(g.IndexStylesheet, "general", "index_stylesheet")
+	v.stylesheet(g.FeedStylesheet, "general", "feed_stylesheet")
+
+	switch g.ValidateOutput {
+	case "":
+		g.ValidateOutput = ValidateOff
+	case ValidateOff, ValidateWarn, ValidateError:
+	default:
+		v.errorf(unknownValue(g.ValidateOutput), "general", "validate_output")
+	}
+
+	if g.Serve != nil {
+		if g.Serve.Enable && g.Serve.Listen == "" {
+			v.errorf(ErrMissingServeListen, "general", "serve", "listen")
+		}
+
+		switch {
+		case g.Serve.StaleFactor == 0:
+			g.Serve.StaleFactor = DefaultStaleFactor
+		case g.Serve.StaleFactor < 0:
+			v.errorf(ErrInvalidStaleFactor, "general", "serve", "stale_factor")
+		}
+
+		if a := g.Serve.Admin; a != nil && a.Enabled {
+			switch {
+			case a.Token == "" && g.Serve.Auth == nil:
+				v.errorf(ErrMissingAdminToken, "general", "serve", "admin", "token")
+			case a.Token != "" && g.Serve.Auth != nil:
+				v.warnf(errors.New("admin token is ignored, serve auth is used"), "general", "serve", "admin", "token")
+			}
+			if !g.Serve.Enable {
+				v.warnf(errors.New("admin api is served only when serve is enabled"), "general", "serve", "admin")
+			}
+		}
+
+		v.serveSecurity(g.Serve)
+	}
+
+	if f := g.Fetch; f != nil {
+		v.notNegative([]string{"general", "fetch"},
+			field{"page_size", int64(f.PageSize)},
+			field{"concurrency", int64(f.Concurrency)},
+			field{"index_concurrency", int64(f.IndexConcurrency)},
+		)
+
+		if f.RateLimit < 0 {
+			v.errorf(ErrNegativeValue, "general", "fetch", "rate_limit")
+		}
+	}
+
+	if g.MeiliSearch == nil {
+		v.errorf(ErrMissingMeilisearchConfig, "general", "meilisearch")
+		return
+	}
+
+	if g.MeiliSearch.Host == "" {
+		v.errorf(ErrMeilisearchHostRequire, "general", "meilisearch", "host")
+	} else if !isAbsoluteURL(g.MeiliSearch.Host) {
+		v.errorf(ErrInvalidMeilisearchHost, "general", "meilisearch", "host")
+	}
+
+	if g.MeiliSearch.APIKey == "" {
+		v.warnf(errors.New("api_key is empty, requests are sent without authorization"),
+			"general", "meilisearch", "api_key")
+	}
+
+	if r := g.MeiliSearch.Retry; r != nil {
+		path := []string{"general", "meilisearch", "retry"}
+		if r.MaxAttempts != nil {
+			v.notNegative(path, field{"max_attempts", int64(*r.MaxAttempts)})
+		}
+		v.notNegative(path,
+			field{"startup_attempts", int64(r.StartupAttempts)},
+			field{"initial_backoff", int64(r.InitialBackoff)},
+			field{"max_backoff", int64(r.MaxBackoff)},
+			field{"timeout", int64(r.Timeout)},
+			field{"breaker_threshold", int64(r.BreakerThreshold)},
+			field{"breaker_cooldown", int64(r.BreakerCooldown)},
+		)
+
+		if r.Jitter != nil && (*r.Jitter < 0 || *r.Jitter > 1) {
+			v.errorf(ErrInvalidJitter, append(path, "jitter")...)
+		}
+	}
+}
+
+func (v *validator) sitemaps() {
+	if len(v.cfg.Sitemaps) == 0 {
+		v.warnf(errors.New("no sitemap configured"), "sitemaps")
+		return
+	}
+
+	names := make([]string, 0, len(v.cfg.Sitemaps))
+	for name := range v.cfg.Sitemaps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	txt := false
+	for _, name := range names {
+		v.sitemap(name, v.cfg.Sitemaps[name])
+		txt = txt || (v.cfg.Sitemaps[name] != nil && v.cfg.Sitemaps[name].Txt)
+	}
+
+	if g := v.cfg.General; g != nil && g.TxtIndex && !txt {
+		v.warnf(errors.New("no sitemap with txt, text index is empty"), "general", "txt_index")
+	}
+}
+
+func (v *validator) sitemap(name string, sm *SitemapConfig) {
+	if name == "" {
+		v.errorf(ErrIndexNameIsEmpty, "sitemaps")
+		return
+	}
+
+	path := []string{"sitemaps", name}
+
+	if sm == nil || !sm.Sitemap {
+		v.errorf(ErrInvalidSitemapConfig, append(path, "sitemap")...)
+		return
+	}
+
+	if sm.HTMLSitemap {
+		v.warnf(errors.New("html sitemap is not supported yet, ignored"), append(path, "html_sitemap")...)
+	}
+
+	if sm.BaseAddress == "" {
+		v.errorf(ErrMissingBaseAddressSitemap, append(path, "base_address")...)
+	} else if !isAbsoluteURL(sm.BaseAddress) {
+		v.errorf(ErrInvalidBaseAddress, append(path, "base_address")...)
+	}
+
+	if sm.NewsSitemap {
+		v.newsSitemap(sm, path)
+	}
+
+	if sm.RSS || sm.Feed != nil {
+		v.feed(name, sm, path)
+	}
+
+	if sm.LiveUpdate != nil && sm.LiveUpdate.Enabled {
+		v.liveUpdate(sm.LiveUpdate, append(path, "live_update"))
+	}
+
+	if sm.Fetch != nil {
+		v.notNegative(append(path, "fetch"),
+			field{"page_size", int64(sm.Fetch.PageSize)},
+			field{"concurrency", int64(sm.Fetch.Concurrency)},
+		)
+	}
+
+	if sm.Snapshot != nil {
+		v.notNegative(append(path, "snapshot"), field{"timeout", int64(sm.Snapshot.Timeout)})
+	}
+
+	if r := sm.Retention; r != nil {
+		if r.MaxShrink < 0 || r.MaxShrink > 100 {
+			v.errorf(ErrInvalidMaxShrink, append(path, "retention", "max_shrink")...)
+		}
+		v.notNegative(append(path, "retention"), field{"grace_period", int64(r.GracePeriod)})
+	}
+
+	if sm.FieldMap == nil {
+		v.errorf(ErrInvalidFieldMap, append(path, "field_map")...)
+		return
+	}
+
+	v.fieldMap(sm.FieldMap, append(path, "field_map"))
+
+	if sm.Feed != nil && (sm.FieldMap.Feed == nil || sm.FieldMap.Feed.Title == "") {
+		v.warnf(errors.New("title of feed items is not mapped, url is used"), append(path, "field_map", "feed", "title")...)
+	}
+
+	if sm.NewsSitemap && sm.FieldMap.News == nil {
+		v.errorf(ErrMissingNewsFieldMap, append(path, "field_map", "news")...)
+	}
+
+	if sm.Fetch != nil {
+		v.fetchStrategy(sm.Fetch, sm.FieldMap, append(path, "fetch"))
+	}
+
+	if sm.Fetch != nil && len(sm.Fetch.Fields) != 0 && !slices.Contains(sm.Fetch.Fields, "*") {
+		for _, attr := range sm.FieldMap.Attributes() {
+			if !slices.Contains(sm.Fetch.Fields, attr) {
+				v.warnf(fmt.Errorf("attribute %q of field_map is not fetched", attr), append(path, "fetch", "fields")...)
+			}
+		}
+	}
+}
+
+func (v *validator) fieldMap(fm *FieldMapConfig, path []string) {
+	if fm.UniqueField == "" {
+		v.errorf(ErrInvalidUniqueField, append(path, "unique_field")...)
+	}
+
+	switch fm.ChangeFreq {
+	case "":
+		fm.ChangeFreq = Daily
+	case Always, Hourly, Daily, Weekly, Monthly, Yearly, Never:
+	default:
+		v.errorf(unknownValue(fm.ChangeFreq), append(path, "changefreq")...)
+	}
+
+	switch fm.Priority {
+	case "":
+		fm.Priority = High
+	case Low, Medium, High, Highest:
+	default:
+		v.errorf(unknownValue(fm.Priority), append(path, "priority")...)
+	}
+
+	if fm.LastMod == "" {
+		v.warnf(errors.New("lastmod is not mapped, current time is used"), append(path, "lastmod")...)
+	}
+
+	if fm.Image != nil && fm.Image.Loc == "" {
+		v.errorf(ErrMissingImageLoc, append(path, "image", "loc")...)
+	}
+
+	if fm.Video != nil {
+		required := map[string]string{
+			"thumbnail_loc": fm.Video.ThumbnailLoc,
+			"title":         fm.Video.Title,
+			"description":   fm.Video.Description,
+		}
+		for _, key := range []string{"thumbnail_loc", "title", "description"} {
+			if required[key] == "" {
+				v.warnf(errors.New("required by video sitemap but not mapped"), append(path, "video", key)...)
+			}
+		}
+
+		if fm.Video.ContentLoc == "" && fm.Video.PlayerLoc == "" {
+			v.warnf(errors.New("one of content_loc or player_loc is required by video sitemap"),
+				append(path, "video")...)
+		}
+	}
+
+	if fm.News != nil {
+		if fm.News.Publication == nil || fm.News.Publication.Name == "" || fm.News.Publication.Language == "" {
+			v.warnf(errors.New("publication name and language are required by news sitemap"),
+				append(path, "news", "publication")...)
+		}
+
+		if fm.News.PubDate == "" {
+			v.warnf(errors.New("required by news sitemap but not mapped"), append(path, "news", "pub_date")...)
+		}
+
+		if fm.News.Title == "" {
+			v.warnf(errors.New("required by news sitemap but not mapped"), append(path, "news", "title")...)
+		}
+	}
+}
+
+// feed add rss format by rss shorthand and apply defaults of feed.
+func (v *validator) feed(name string, sm *SitemapConfig, path []string) {
+	if sm.Feed == nil {
+		sm.Feed = new(FeedConfig)
+	}
+	f := sm.Feed
+	path = append(path, "feed")
+
+	if (sm.RSS || len(f.Formats) == 0) && !slices.Contains(f.Formats, FeedRSS) {
+		f.Formats = append(f.Formats, FeedRSS)
+	}
+
+	for _, format := range f.Formats {
+		switch format {
+		case FeedRSS, FeedAtom, FeedJSON:
+		default:
+			v.errorf(unknownValue(format), append(path, "formats")...)
+		}
+	}
+
+	if f.Title == "" {
+		f.Title = name
+	}
+
+	if f.Link == "" {
+		f.Link = sm.BaseAddress
+	}
+
+	if f.Icon != "" && !isAbsoluteURL(f.Icon) {
+		v.errorf(ErrInvalidFeedIcon, append(path, "icon")...)
+	}
+
+	switch {
+	case f.MaxItems == 0:
+		f.MaxItems = DefaultFeedMaxItems
+	case f.MaxItems < 0:
+		v.notNegative(path, field{"max_items", int64(f.MaxItems)})
+	}
+}
+
+// newsSitemap regenerate news sitemap frequently by default, so articles leave it after news window.
+// Retention is dropped, news sitemap shrinks as articles get old.
+func (v *validator) newsSitemap(sm *SitemapConfig, path []string) {
+	switch live := sm.LiveUpdate; {
+	case live == nil:
+		sm.LiveUpdate = &LiveConfig{Enabled: true, Every: DefaultNewsEvery, RunAtStart: true}
+	case !live.Enabled || live.Mode == LiveTasks:
+		v.warnf(errors.New("news sitemap keeps old articles until next run, use interval mode"),
+			append(path, "live_update")...)
+	}
+
+	if sm.Retention != nil {
+		v.warnf(errors.New("retention is ignored by news sitemap"), append(path, "retention")...)
+		sm.Retention = nil
+	}
+}
+
+func (v *validator) liveUpdate(live *LiveConfig, path []string) {
+	switch live.Mode {
+	case "":
+		live.Mode = LiveInterval
+	case LiveInterval, LiveTasks:
+	default:
+		v.errorf(unknownValue(live.Mode), append(path, "mode")...)
+	}
+
+	if live.Mode == LiveInterval {
+		v.liveSchedule(live, path)
+	}
+
+	switch live.Overlap {
+	case "":
+		// changes of index must not be lost by a run of tasks while previous run is running.
+		live.Overlap = OverlapSkip
+		if live.Mode == LiveTasks {
+			live.Overlap = OverlapQueue
+		}
+	case OverlapSkip, OverlapQueue:
+	default:
+		v.errorf(unknownValue(live.Overlap), append(path, "overlap")...)
+	}
+
+	v.notNegative(path,
+		field{"poll_interval", int64(live.PollInterval)},
+		field{"debounce", int64(live.Debounce)},
+		field{"max_delay", int64(live.MaxDelay)},
+		field{"jitter", int64(live.Jitter)},
+	)
+}
+
+// stylesheet must be bundled, url or path of xsl file, reports it's valid.
+func (v *validator) stylesheet(s Stylesheet, path ...string) bool {
+	if s == "" || s.Bundled() || s.IsURL() {
+		return true
+	}
+
+	switch strings.ToLower(filepath.Ext(string(s))) {
+	case ".xsl", ".xslt":
+		return true
+	default:
+		v.errorf(unknownValue(s), path...)
+		return false
+	}
+}
+
+func (v *validator) serveSecurity(serve *ServeConfig) {
+	path := []string{"general", "serve"}
+
+	if t := serve.TLS; t != nil && (t.CertFile == "" || t.KeyFile == "") {
+		v.errorf(ErrMissingTLSFile, append(path, "tls")...)
+	}
+
+	if a := serve.Auth; a != nil {
+		switch a.Type {
+		case AuthBasic:
+			if a.Username == "" || a.Password == "" {
+				v.errorf(fmt.Errorf("%w, username and password of basic auth", ErrMissingAuthCredentials), append(path, "auth")...)
+			}
+		case AuthBearer:
+			if a.Token == "" {
+				v.errorf(fmt.Errorf("%w, token of bearer auth", ErrMissingAuthCredentials), append(path, "auth")...)
+			}
+		default:
+			v.errorf(unknownValue(a.Type), append(path, "auth", "type")...)
+		}
+	}
+
+	for _, cidr := range serve.AllowCIDRs {
+		if _, err := ParseCIDR(cidr); err != nil {
+			v.errorf(fmt.Errorf("%w %q", ErrInvalidCIDR, cidr), append(path, "allow_cidrs")...)
+		}
+	}
+}
+
+func (v *validator) liveSchedule(live *LiveConfig, path []string) {
+	set := 0
+	for _, ok := range []bool{live.Interval != 0, live.Every != "", live.Cron != ""} {
+		if ok {
+			set++
+		}
+	}
+
+	switch {
+	case set == 0 || live.Interval < 0:
+		v.errorf(ErrInvalidLiveInterval, append(path, "interval")...)
+	case set > 1:
+		v.errorf(ErrConflictingSchedule, path...)
+	}
+
+	if live.Every != "" && live.Every != EveryChangeFreq {
+		if d, err := time.ParseDuration(live.Every); err != nil || d <= 0 {
+			v.errorf(fmt.Errorf("%w %q", ErrInvalidDuration, live.Every), append(path, "every")...)
+		}
+	}
+
+	if live.Cron != "" {
+		if _, err := cron.ParseStandard(live.Cron); err != nil {
+			v.errorf(fmt.Errorf("%w %q: %w", ErrInvalidCron, live.Cron, err), append(path, "cron")...)
+		}
+	}
+
+	if live.Timezone != "" {
+		if _, err := time.LoadLocation(live.Timezone); err != nil {
+			v.errorf(fmt.Errorf("%w %q", ErrInvalidTimezone, live.Timezone), append(path, "timezone")...)
+		} else if live.Cron == "" {
+			v.warnf(errors.New("timezone is used only by cron, ignored"), append(path, "timezone")...)
+		}
+	}
+}
+
+func (v *validator) fetchStrategy(f *IndexFetch, fm *FieldMapConfig, path []string) {
+	switch f.Strategy {
+	case "":
+		f.Strategy = FetchOffset
+	case FetchOffset, FetchCursor:
+	default:
+		v.errorf(unknownValue(f.Strategy), append(path, "strategy")...)
+	}
+
+	if f.Strategy == FetchCursor && f.CursorKey == "" {
+		f.CursorKey = fm.UniqueField
+	}
+}
+
+type field struct {
+	key string
+	val int64
+}
+
+func (v *validator) notNegative(path []string, fields ...field) {
+	for _, f := range fields {
+		if f.val < 0 {
+			v.errorf(ErrNegativeValue, append(append([]string{}, path...), f.key)...)
+		}
+	}
+}
+
+// unknownKeys walk yaml node along with go type and report keys which not exists in type.
+func (v *validator) unknownKeys(node *yaml.Node, t reflect.Type, path []string, sev Severity) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) != 0 {
+			v.unknownKeys(node.Content[0], t, path, sev)
+		}
+		return
+	case yaml.AliasNode:
+		v.unknownKeys(node.Alias, t, path, sev)
+		return
+	case yaml.MappingNode:
+	default:
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Map:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			v.unknownKeys(node.Content[i+1], t.Elem(), append(path, node.Content[i].Value), sev)
+		}
+	case reflect.Struct:
+		fields := make(map[string]reflect.Type, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+			if !f.IsExported() || name == "" || name == "-" {
+				continue
+			}
+			fields[name] = f.Type
+		}
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			keyPath := append(append([]string{}, path...), key.Value)
+
+			ft, ok := fields[key.Value]
+			if !ok {
+				v.issues = append(v.issues, &Issue{
+					Path:     strings.Join(keyPath, "."),
+					Line:     key.Line,
+					Severity: sev,
+					Err:      ErrUnknownKey,
+				})
+				continue
+			}
+
+			v.unknownKeys(node.Content[i+1], ft, keyPath, sev)
+		}
+	}
+}
+
+// line returns line of path in config file, if path not exists returns line of nearest parent.
+func (v *validator) line(path ...string) int {
+	node := v.cfg.node
+	if node == nil {
+		return 0
+	}
+
+	if node.Kind == yaml.DocumentNode && len(node.Content) != 0 {
+		node = node.Content[0]
+	}
+
+	line := 0
+
+	for _, key := range path {
+		if node.Kind != yaml.MappingNode {
+			break
+		}
+
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				line = node.Content[i].Line
+				next = node.Content[i+1]
+				break
+			}
+		}
+
+		if next == nil {
+			break
+		}
+
+		node = next
+	}
+
+	return line
+}
+
+func unknownValue[T ~string](val T) error {
+	return fmt.Errorf("%w %q", ErrUnknownValue, string(val))
+}
+
+func isAbsoluteURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return u.Scheme != "" && u.Host != ""
+}
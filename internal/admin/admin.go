@@ -0,0 +1,178 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Ja7ad/meilisitemap/internal/report"
+	"github.com/Ja7ad/meilisitemap/internal/sched"
+)
+
+// Prefix of admin API routes.
+const Prefix = "/api/admin/"
+
+const (
+	_defaultPreviewLimit = 10
+	_maxPreviewLimit     = 1000
+)
+
+var (
+	ErrNotFound   = errors.New("sitemap not found")
+	ErrRunning    = errors.New("sitemap generation is running")
+	ErrNotRunning = errors.New("sitemap generation is not running")
+)
+
+// Manager is sitemaps generator managed by admin API.
+type Manager interface {
+	// Sitemaps returns every configured sitemap sorted by index.
+	Sitemaps() []*Sitemap
+	// Config returns effective config with secrets redacted.
+	Config() (map[string]any, error)
+	// Run trigger generation of index.
+	Run(index string) error
+	// Cancel cancel running generation of index.
+	Cancel(index string) error
+	// Preview returns first limit urls of sitemap of index, nothing is written.
+	Preview(ctx context.Context, index string, limit int) ([]*URL, error)
+}
+
+// Sitemap is state of a configured sitemap.
+type Sitemap struct {
+	Index   string           `json:"index"`
+	Live    string           `json:"live,omitempty"` // Live is mode of live update, empty when generated once
+	File    string           `json:"file"`
+	Link    string           `json:"link"`
+	URLs    int              `json:"urls"` // URLs of last successful run
+	LastRun *report.Index    `json:"last_run,omitempty"`
+	Job     *sched.JobStatus `json:"job,omitempty"`
+}
+
+// URL is url of preview.
+type URL struct {
+	Loc        string `json:"loc"`
+	LastMod    string `json:"lastmod,omitempty"`
+	ChangeFreq string `json:"changefreq,omitempty"`
+	Priority   string `json:"priority,omitempty"`
+}
+
+type api struct {
+	m Manager
+}
+
+// Handler serve admin API of m, every request must have token as bearer authorization,
+// empty token leave authorization to server.
+func Handler(token string, m Manager) http.Handler {
+	a := &api{m: m}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+Prefix+"sitemaps", a.sitemaps)
+	mux.HandleFunc("GET "+Prefix+"sitemaps/{index}", a.sitemap)
+	mux.HandleFunc("GET "+Prefix+"sitemaps/{index}/preview", a.preview)
+	mux.HandleFunc("POST "+Prefix+"sitemaps/{index}/run", a.run)
+	mux.HandleFunc("POST "+Prefix+"sitemaps/{index}/cancel", a.cancel)
+	mux.HandleFunc("GET "+Prefix+"config", a.config)
+
+	if token == "" {
+		return mux
+	}
+
+	return bearer(token, mux)
+}
+
+func bearer(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			writeError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (a *api) sitemaps(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, a.m.Sitemaps())
+}
+
+func (a *api) sitemap(w http.ResponseWriter, r *http.Request) {
+	idx := r.PathValue("index")
+	for _, sm := range a.m.Sitemaps() {
+		if sm.Index == idx {
+			writeJSON(w, http.StatusOK, sm)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, ErrNotFound)
+}
+
+func (a *api) preview(w http.ResponseWriter, r *http.Request) {
+	limit := _defaultPreviewLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, errors.New("limit must be a positive number"))
+			return
+		}
+		limit = min(n, _maxPreviewLimit)
+	}
+
+	urls, err := a.m.Preview(r.Context(), r.PathValue("index"), limit)
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, urls)
+}
+
+func (a *api) run(w http.ResponseWriter, r *http.Request) {
+	if err := a.m.Run(r.PathValue("index")); err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (a *api) cancel(w http.ResponseWriter, r *http.Request) {
+	if err := a.m.Cancel(r.PathValue("index")); err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (a *api) config(w http.ResponseWriter, _ *http.Request) {
+	cfg, err := a.m.Config()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, cfg)
+}
+
+func statusOf(err error) int {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrRunning), errors.Is(err, ErrNotRunning):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
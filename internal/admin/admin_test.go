@@ -0,0 +1,106 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeManager struct {
+	running bool
+	limit   int
+}
+
+func (f *fakeManager) Sitemaps() []*Sitemap {
+	return []*Sitemap{{Index: "movies", File: "sitemaps/movies.xml"}}
+}
+
+func (f *fakeManager) Config() (map[string]any, error) {
+	return map[string]any{"general": map[string]any{"base_index_url": "https://example.com"}}, nil
+}
+
+func (f *fakeManager) Run(index string) error {
+	if index != "movies" {
+		return ErrNotFound
+	}
+	if f.running {
+		return fmt.Errorf("%w: %s", ErrRunning, index)
+	}
+	f.running = true
+	return nil
+}
+
+func (f *fakeManager) Cancel(index string) error {
+	if !f.running {
+		return ErrNotRunning
+	}
+	f.running = false
+	return nil
+}
+
+func (f *fakeManager) Preview(_ context.Context, index string, limit int) ([]*URL, error) {
+	if index != "movies" {
+		return nil, ErrNotFound
+	}
+	f.limit = limit
+	return []*URL{{Loc: "https://example.com/movies/1"}}, nil
+}
+
+func TestHandler(t *testing.T) {
+	m := new(fakeManager)
+	h := Handler("secret", m)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		code   int
+		limit  int
+	}{
+		{name: "missing token", method: http.MethodGet, path: "sitemaps", code: http.StatusUnauthorized},
+		{name: "wrong token", method: http.MethodGet, path: "sitemaps", token: "wrong", code: http.StatusUnauthorized},
+		{name: "list", method: http.MethodGet, path: "sitemaps", token: "secret", code: http.StatusOK},
+		{name: "get", method: http.MethodGet, path: "sitemaps/movies", token: "secret", code: http.StatusOK},
+		{name: "get unknown", method: http.MethodGet, path: "sitemaps/series", token: "secret", code: http.StatusNotFound},
+		{name: "config", method: http.MethodGet, path: "config", token: "secret", code: http.StatusOK},
+		{name: "cancel not running", method: http.MethodPost, path: "sitemaps/movies/cancel", token: "secret", code: http.StatusConflict},
+		{name: "run", method: http.MethodPost, path: "sitemaps/movies/run", token: "secret", code: http.StatusAccepted},
+		{name: "run running", method: http.MethodPost, path: "sitemaps/movies/run", token: "secret", code: http.StatusConflict},
+		{name: "cancel", method: http.MethodPost, path: "sitemaps/movies/cancel", token: "secret", code: http.StatusAccepted},
+		{name: "run unknown", method: http.MethodPost, path: "sitemaps/series/run", token: "secret", code: http.StatusNotFound},
+		{name: "run by get", method: http.MethodGet, path: "sitemaps/movies/run", token: "secret", code: http.StatusMethodNotAllowed},
+		{name: "preview", method: http.MethodGet, path: "sitemaps/movies/preview", token: "secret", code: http.StatusOK, limit: 10},
+		{name: "preview limit", method: http.MethodGet, path: "sitemaps/movies/preview?limit=5000", token: "secret", code: http.StatusOK, limit: 1000},
+		{name: "preview bad limit", method: http.MethodGet, path: "sitemaps/movies/preview?limit=-1", token: "secret", code: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m.limit = 0
+
+			req := httptest.NewRequest(tt.method, Prefix+tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.code, rec.Code, rec.Body.String())
+			assert.Equal(t, tt.limit, m.limit)
+
+			if rec.Code >= http.StatusBadRequest && rec.Code != http.StatusMethodNotAllowed {
+				var body map[string]string
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+				assert.NotEmpty(t, body["error"])
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+
+	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/Ja7ad/meilisitemap/internal/admin"
+	"github.com/Ja7ad/meilisitemap/internal/report"
+	"github.com/Ja7ad/meilisitemap/internal/sched"
+)
+
+// manager is generator managed by admin api.
+type manager struct {
+	s *Sitemap
+}
+
+func (m *manager) Sitemaps() []*admin.Sitemap {
+	lastRuns := make(map[string]*report.Index)
+	if m.s.report != nil {
+		for _, ir := range m.s.report.Report().Indexes {
+			lastRuns[ir.Index] = ir
+		}
+	}
+
+	res := make([]*admin.Sitemap, 0, len(m.s.sitemaps))
+	for idx, sm := range m.s.sitemaps {
+		fn := m.s.sitemapFileName(idx, sm)
+		link, _ := url.JoinPath(m.s.baseLoc(), m.s.indexsitemapPath, fn)
+
+		st := &admin.Sitemap{
+			Index:   idx,
+			File:    filepath.Join(m.s.indexsitemapPath, fn),
+			Link:    link,
+			LastRun: lastRuns[idx],
+		}
+
+		if sm.LiveUpdate != nil && sm.LiveUpdate.Enabled {
+			st.Live = string(sm.LiveUpdate.Mode)
+		}
+
+		if st.LastRun != nil && st.LastRun.Error == "" {
+			st.URLs = st.LastRun.URLs + st.LastRun.Retained
+		}
+
+		if job, err := m.s.sched.Job(idx); err == nil {
+			st.Job = &job
+		}
+
+		res = append(res, st)
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Index < res[j].Index })
+
+	return res
+}
+
+func (m *manager) Config() (map[string]any, error) {
+	cfg := &config.Config{General: m.s.general, Sitemaps: m.s.sitemaps}
+	return cfg.Redact()
+}
+
+func (m *manager) Run(idx string) error {
+	err := m.s.sched.Trigger(idx)
+	switch {
+	case errors.Is(err, sched.ErrJobNotFound):
+		return fmt.Errorf("%w: %s", admin.ErrNotFound, idx)
+	case errors.Is(err, sched.ErrJobRunning):
+		return fmt.Errorf("%w: %s", admin.ErrRunning, idx)
+	}
+	return err
+}
+
+func (m *manager) Cancel(idx string) error {
+	err := m.s.sched.Cancel(idx)
+	switch {
+	case errors.Is(err, sched.ErrJobNotFound):
+		return fmt.Errorf("%w: %s", admin.ErrNotFound, idx)
+	case errors.Is(err, sched.ErrNotRunning):
+		return fmt.Errorf("%w: %s", admin.ErrNotRunning, idx)
+	}
+	return err
+}
+
+// Preview build urls of index the same way as generate, fetch strategy and news order
+// included, and returns first limit of them.
+func (m *manager) Preview(ctx context.Context, idx string, limit int) ([]*admin.URL, error) {
+	sm, ok := m.s.sitemaps[idx]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", admin.ErrNotFound, idx)
+	}
+
+	results, err := m.s.fetchIndexDocuments(ctx, idx, sm)
+	if err != nil {
+		return nil, err
+	}
+
+	urlSet, _ := m.s.sm.BuildURLSet(ctx, idx, results)
+	urls := urlSet.URLs[:min(limit, len(urlSet.URLs))]
+
+	res := make([]*admin.URL, 0, len(urls))
+	for _, u := range urls {
+		res = append(res, &admin.URL{
+			Loc:        u.Loc,
+			LastMod:    u.LastMod,
+			ChangeFreq: string(u.ChangeFreq),
+			Priority:   u.Priority,
+		})
+	}
+
+	return res, nil
+}
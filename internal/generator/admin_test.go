@@ -0,0 +1,68 @@
+package generator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/Ja7ad/meilisitemap/internal/admin"
+	"github.com/Ja7ad/meilisitemap/internal/logger"
+	"github.com/Ja7ad/meilisitemap/internal/meilitest"
+	"github.com/Ja7ad/meilisitemap/internal/sched"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager(t *testing.T) {
+	srv := meilitest.New()
+	defer srv.Close()
+	// documents skipped by field map don't shrink preview.
+	docs := append([]map[string]any{{"created_at": "2024-01-02T15:04:05Z"}}, newTestDocs(30)...)
+	srv.AddIndex("movies", docs, nil)
+
+	general, sitemaps := newTestConfig(srv.URL)
+	general.MeiliSearch.APIKey = "masterKey"
+
+	g, err := New(context.Background(), t.TempDir(), general, logger.DefaultLogger, sitemaps)
+	require.NoError(t, err)
+	m := &manager{g}
+
+	urls, err := m.Preview(context.Background(), "movies", 5)
+	require.NoError(t, err)
+	require.Len(t, urls, 5)
+	assert.Equal(t, "https://example.com/movies/1", urls[0].Loc)
+	assert.Equal(t, "daily", urls[0].ChangeFreq)
+
+	_, err = m.Preview(context.Background(), "series", 5)
+	assert.ErrorIs(t, err, admin.ErrNotFound)
+
+	require.NoError(t, g.generate(context.Background(), "movies", sitemaps["movies"]))
+
+	release := make(chan struct{})
+	require.NoError(t, g.sched.Add("movies", func(ctx context.Context) error {
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return ctx.Err()
+	}, sched.Manual))
+
+	list := m.Sitemaps()
+	require.Len(t, list, 1)
+	assert.Equal(t, "movies", list[0].Index)
+	assert.Equal(t, "sitemaps/movies.xml", list[0].File)
+	assert.Equal(t, 30, list[0].URLs)
+	require.NotNil(t, list[0].LastRun)
+	require.NotNil(t, list[0].Job)
+
+	assert.ErrorIs(t, m.Cancel("movies"), admin.ErrNotRunning)
+	require.NoError(t, m.Run("movies"))
+	assert.ErrorIs(t, m.Run("movies"), admin.ErrRunning)
+	require.NoError(t, m.Cancel("movies"))
+	assert.ErrorIs(t, m.Run("series"), admin.ErrNotFound)
+	close(release)
+
+	cfg, err := m.Config()
+	require.NoError(t, err)
+	assert.Equal(t, config.Redacted, cfg["general"].(map[string]any)["meilisearch"].(map[string]any)["api_key"])
+}
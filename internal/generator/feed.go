@@ -0,0 +1,61 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+
+	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/Ja7ad/meilisitemap/internal/metrics"
+	"github.com/Ja7ad/meilisitemap/internal/sitemap"
+	"github.com/Ja7ad/meilisitemap/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// _feedExt of feed files by format, feeds are written next to sitemap of index.
+var _feedExt = map[config.FeedFormat]string{
+	config.FeedRSS:  ".rss",
+	config.FeedAtom: ".atom",
+	config.FeedJSON: ".feed.json",
+}
+
+// writeFeeds build feed of index documents and write it in every format of feed config.
+func (s *Sitemap) writeFeeds(ctx context.Context, idx string, sm *config.SitemapConfig, docs []map[string]any) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "generator.writeFeeds", trace.WithAttributes(attribute.String("index", idx)))
+	defer func() {
+		tracing.End(span, err)
+	}()
+
+	feed := s.sm.BuildFeed(ctx, idx, docs)
+
+	for _, format := range sm.Feed.Formats {
+		fileName := s.feedFileName(idx, sm, format)
+
+		feed.Self, err = url.JoinPath(s.baseLoc(), s.indexsitemapPath, fileName)
+		if err != nil {
+			return err
+		}
+
+		b, err := sitemap.EncodeFeed(feed, format, s.feedStylesheet)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s feed: %w", format, err)
+		}
+
+		file := filepath.Join(s.indexsitemapPath, fileName)
+		path := filepath.Join(s.storePath, file)
+
+		if err := writeFileAtomic(path, b); err != nil {
+			return fmt.Errorf("error writing feed %s: %w", path, err)
+		}
+
+		metrics.SetFileSize(file, len(b))
+	}
+
+	return nil
+}
+
+func (s *Sitemap) feedFileName(idx string, sm *config.SitemapConfig, format config.FeedFormat) string {
+	return s.baseFileName(idx, sm) + _feedExt[format]
+}
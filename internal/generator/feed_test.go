@@ -0,0 +1,64 @@
+package generator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/Ja7ad/meilisitemap/internal/logger"
+	"github.com/Ja7ad/meilisitemap/internal/meilitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateFeeds(t *testing.T) {
+	srv := meilitest.New()
+	defer srv.Close()
+	srv.AddIndex("movies", newTestDocs(3), nil)
+
+	store := t.TempDir()
+	general, sitemaps := newTestConfig(srv.URL)
+	general.FeedStylesheet = config.Style1
+	sitemaps["movies"].Feed = &config.FeedConfig{
+		Title:    "Movies",
+		Link:     "https://example.com",
+		MaxItems: 2,
+		Formats:  []config.FeedFormat{config.FeedRSS, config.FeedAtom, config.FeedJSON},
+	}
+
+	g, err := New(context.Background(), store, general, logger.DefaultLogger, sitemaps, WithOnce())
+	require.NoError(t, err)
+	require.NoError(t, g.Start())
+
+	tests := []struct {
+		file string
+		self string
+	}{
+		{
+			file: "movies.rss",
+			self: `<atom:link href="https://example.com/sitemaps/movies.rss" rel="self" type="application/rss+xml">`,
+		},
+		{
+			file: "movies.atom",
+			self: `<link href="https://example.com/sitemaps/movies.atom" rel="self" type="application/atom+xml">`,
+		},
+		{
+			file: "movies.feed.json",
+			self: `"feed_url": "https://example.com/sitemaps/movies.feed.json"`,
+		},
+	}
+
+	for _, tt := range tests {
+		b, err := os.ReadFile(filepath.Join(store, "sitemaps", tt.file))
+		require.NoError(t, err)
+		assert.Contains(t, string(b), tt.self)
+		assert.Contains(t, string(b), "https://example.com/movies/1")
+		assert.NotContains(t, string(b), "https://example.com/movies/3")
+	}
+
+	b, err := os.ReadFile(filepath.Join(store, "sitemaps", "movies.rss"))
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `<?xml-stylesheet type="text/xsl" href="https://example.com/stylesheets/style1.xsl"?>`)
+}
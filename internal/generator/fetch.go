@@ -0,0 +1,374 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/Ja7ad/meilisitemap/internal/metrics"
+	"github.com/Ja7ad/meilisitemap/internal/tracing"
+	"github.com/Ja7ad/meilisitemap/utils"
+	"github.com/meilisearch/meilisearch-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
+)
+
+const (
+	_defaultPageSize         = 100
+	_defaultConcurrency      = 8
+	_defaultIndexConcurrency = 4
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// _filterEscaper escapes string of filter expression quoted by double quotes.
+var _filterEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+type fetchOptions struct {
+	pageSize         int
+	indexConcurrency int
+}
+
+// setupFetch set page size, global concurrency and rate limit of fetching pages.
+func (s *Sitemap) setupFetch(cfg *config.FetchConfig) {
+	if cfg == nil {
+		cfg = new(config.FetchConfig)
+	}
+
+	s.fetch = fetchOptions{
+		pageSize:         orDefault(cfg.PageSize, _defaultPageSize),
+		indexConcurrency: orDefault(cfg.IndexConcurrency, _defaultIndexConcurrency),
+	}
+	s.fetchSem = make(chan struct{}, orDefault(cfg.Concurrency, _defaultConcurrency))
+
+	if cfg.RateLimit > 0 {
+		s.limiter = rate.NewLimiter(rate.Limit(cfg.RateLimit), 1)
+	}
+}
+
+// fetchIndexDocuments fetch first page of index to get total, then rest of pages are fetched
+// by a bounded pool of workers. Pages are merged by offset so output order is deterministic.
+func (s *Sitemap) fetchIndexDocuments(
+	ctx context.Context, index string, sm *config.SitemapConfig,
+) (results []map[string]interface{}, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "generator.fetchIndexDocuments", trace.WithAttributes(attribute.String("index", index)))
+	defer func() {
+		span.SetAttributes(attribute.Int("documents", len(results)))
+		tracing.End(span, err)
+	}()
+
+	// documents may be fetched from another index, see config.SnapshotConfig.
+	index = sm.SourceIndex(index)
+	span.SetAttributes(attribute.String("source", index))
+
+	pageSize, concurrency := s.fetch.pageSize, s.fetch.indexConcurrency
+	if sm.Fetch != nil {
+		pageSize = orDefault(sm.Fetch.PageSize, pageSize)
+		concurrency = orDefault(sm.Fetch.Concurrency, concurrency)
+	}
+
+	var filter interface{}
+	if sm.Filter != "" {
+		filter = sm.Filter
+	}
+
+	fields := fetchFields(sm)
+	span.SetAttributes(attribute.StringSlice("fields", fields))
+
+	if sm.Fetch != nil && sm.Fetch.Strategy == config.FetchCursor {
+		span.SetAttributes(attribute.String("strategy", string(config.FetchCursor)))
+		return s.fetchByCursor(ctx, index, sm, pageSize, fields)
+	}
+
+	query := func(page int) *meilisearch.DocumentsQuery {
+		return &meilisearch.DocumentsQuery{
+			Offset: int64(page * pageSize),
+			Limit:  int64(pageSize),
+			Fields: fields,
+			Filter: filter,
+		}
+	}
+
+	first := new(meilisearch.DocumentsResult)
+	if err := s.getDocuments(ctx, index, query(0), first); err != nil {
+		return nil, err
+	}
+
+	pages := make([][]map[string]interface{}, (first.Total+int64(pageSize)-1)/int64(pageSize))
+	if len(pages) == 0 {
+		return first.Results, nil
+	}
+	pages[0] = first.Results
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg      sync.WaitGroup
+		errOnce sync.Once
+		fetchCh = make(chan int)
+	)
+
+	for range min(concurrency, len(pages)-1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for page := range fetchCh {
+				resp := new(meilisearch.DocumentsResult)
+				if fErr := s.getDocuments(ctx, index, query(page), resp); fErr != nil {
+					errOnce.Do(func() {
+						err = fErr
+						cancel()
+					})
+					continue
+				}
+				pages[page] = resp.Results
+			}
+		}()
+	}
+
+dispatch:
+	for page := 1; page < len(pages); page++ {
+		select {
+		case fetchCh <- page:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(fetchCh)
+	wg.Wait()
+
+	if err != nil {
+		return nil, err
+	}
+
+	// parent canceled while dispatching, some pages are not fetched.
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	results = make([]map[string]interface{}, 0, first.Total)
+	for _, page := range pages {
+		results = append(results, page...)
+	}
+
+	return results, nil
+}
+
+// fetchByCursor walk index sorted by cursor key, every page is filtered by key greater than
+// last key of previous page. Cost of every page is constant and documents written while
+// walking are not skipped or duplicated, but pages are fetched one after another.
+func (s *Sitemap) fetchByCursor(
+	ctx context.Context, index string, sm *config.SitemapConfig, pageSize int, fields []string,
+) ([]map[string]interface{}, error) {
+	key := sm.Fetch.CursorKey
+	if key == "" {
+		key = sm.FieldMap.UniqueField
+	}
+
+	if attr, _, _ := strings.Cut(key, "."); fields != nil && !slices.Contains(fields, attr) {
+		fields = append(slices.Clone(fields), attr)
+	}
+
+	var (
+		results = make([]map[string]interface{}, 0)
+		last    interface{}
+	)
+
+	for {
+		filter := make([]string, 0, 2)
+		if sm.Filter != "" {
+			filter = append(filter, "("+sm.Filter+")")
+		}
+
+		if last != nil {
+			cond, err := cursorFilter(key, last)
+			if err != nil {
+				return nil, err
+			}
+			filter = append(filter, cond)
+		}
+
+		req := &meilisearch.SearchRequest{
+			Limit:                int64(pageSize),
+			AttributesToRetrieve: fields,
+			Sort:                 []string{key + ":asc"},
+		}
+
+		if len(filter) != 0 {
+			req.Filter = strings.Join(filter, " AND ")
+		}
+
+		page, err := s.searchDocuments(ctx, index, req)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, page...)
+
+		// search caps limit of request at max total hits of index, a short page
+		// does not mean walk is done.
+		if len(page) == 0 {
+			return results, nil
+		}
+
+		if last = utils.PickByNestedKey(page[len(page)-1], key); last == nil {
+			return nil, fmt.Errorf("%w, document has no %s", ErrInvalidCursor, key)
+		}
+	}
+}
+
+// cursorFilter returns filter condition of documents after cursor value.
+func cursorFilter(key string, val interface{}) (string, error) {
+	switch v := val.(type) {
+	case float64:
+		return key + " > " + strconv.FormatFloat(v, 'f', -1, 64), nil
+	case string:
+		return key + ` > "` + _filterEscaper.Replace(v) + `"`, nil
+	default:
+		return "", fmt.Errorf("%w, %s is %T", ErrInvalidCursor, key, val)
+	}
+}
+
+// searchDocuments fetch a page of documents by search request, see getDocuments.
+func (s *Sitemap) searchDocuments(
+	ctx context.Context, index string, req *meilisearch.SearchRequest,
+) (docs []map[string]interface{}, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "meilisearch.Search", trace.WithAttributes(
+		attribute.String("index", index),
+		attribute.String("filter", fmt.Sprint(req.Filter)),
+		attribute.Int64("limit", req.Limit),
+	))
+	defer func() {
+		span.SetAttributes(attribute.Int("documents", len(docs)))
+		tracing.End(span, err)
+	}()
+
+	var resp *meilisearch.SearchResponse
+	attempts, err := s.request(ctx, index, func(ctx context.Context) (err error) {
+		resp, err = s.meili.Index(index).SearchWithContext(ctx, "", req)
+		return err
+	})
+	span.SetAttributes(attribute.Int("attempts", attempts))
+	if err != nil {
+		return nil, err
+	}
+	metrics.FetchPages.WithLabelValues(index).Inc()
+
+	docs = make([]map[string]interface{}, 0, len(resp.Hits))
+	for _, hit := range resp.Hits {
+		doc, ok := hit.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unexpected hit of index %s, type is %T", index, hit)
+		}
+		docs = append(docs, doc)
+	}
+
+	return docs, nil
+}
+
+// getDocuments fetch a page of documents with retry policy and observe latency of every attempt,
+// every attempt waits for rate limit and a slot of global concurrency.
+func (s *Sitemap) getDocuments(
+	ctx context.Context, index string, query *meilisearch.DocumentsQuery, resp *meilisearch.DocumentsResult,
+) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "meilisearch.GetDocuments", trace.WithAttributes(
+		attribute.String("index", index),
+		attribute.Int64("offset", query.Offset),
+		attribute.Int64("limit", query.Limit),
+	))
+	defer func() {
+		span.SetAttributes(attribute.Int("documents", len(resp.Results)))
+		tracing.End(span, err)
+	}()
+
+	attempts, err := s.request(ctx, index, func(ctx context.Context) error {
+		*resp = meilisearch.DocumentsResult{}
+		return s.meili.Index(index).GetDocumentsWithContext(ctx, query, resp)
+	})
+	span.SetAttributes(attribute.Int("attempts", attempts))
+	if err == nil {
+		metrics.FetchPages.WithLabelValues(index).Inc()
+	}
+
+	return err
+}
+
+// request run fn with retry policy and returns number of attempts.
+func (s *Sitemap) request(ctx context.Context, index string, fn func(ctx context.Context) error) (int, error) {
+	attempts := 0
+
+	err := s.retry.Do(ctx, func(ctx context.Context) error {
+		attempts++
+
+		release, err := s.acquire(ctx)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		start := time.Now()
+		err = fn(ctx)
+		metrics.FetchDuration.WithLabelValues(index).Observe(time.Since(start).Seconds())
+		return err
+	})
+
+	return attempts, err
+}
+
+// acquire wait for rate limit and slot of global concurrency, release must be called after request.
+func (s *Sitemap) acquire(ctx context.Context) (func(), error) {
+	if s.limiter != nil {
+		if err := s.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	select {
+	case s.fetchSem <- struct{}{}:
+		return func() { <-s.fetchSem }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// isRetryable reports error of Meilisearch is temporary, client errors
+// except timeout and rate limit are not retried.
+func isRetryable(err error) bool {
+	var mErr *meilisearch.Error
+	if errors.As(err, &mErr) && mErr.StatusCode >= 400 && mErr.StatusCode < 500 {
+		return mErr.StatusCode == http.StatusRequestTimeout || mErr.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// fetchFields returns attributes requested of index documents, fetch.fields of index
+// override attributes of field map and nil fetch whole documents.
+func fetchFields(sm *config.SitemapConfig) []string {
+	fields := sm.FieldMap.Attributes()
+	if sm.Fetch != nil && len(sm.Fetch.Fields) != 0 {
+		fields = sm.Fetch.Fields
+	}
+
+	if slices.Contains(fields, "*") {
+		return nil
+	}
+
+	return fields
+}
+
+func orDefault(v, def int) int {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,282 @@
+package generator
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/Ja7ad/meilisitemap/internal/logger"
+	"github.com/Ja7ad/meilisitemap/internal/meilitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchIndexDocuments(t *testing.T) {
+	tests := []struct {
+		name        string
+		docs        int
+		fetch       *config.FetchConfig
+		index       *config.IndexFetch
+		pages       int
+		maxInFlight int
+	}{
+		{name: "empty index", docs: 0, pages: 1, maxInFlight: 1},
+		{name: "single page", docs: 80, pages: 1, maxInFlight: 1},
+		{name: "default page size", docs: 1000, pages: 10, maxInFlight: _defaultIndexConcurrency},
+		{
+			name:        "index override",
+			docs:        95,
+			fetch:       &config.FetchConfig{PageSize: 50, IndexConcurrency: 1},
+			index:       &config.IndexFetch{PageSize: 10, Concurrency: 3},
+			pages:       10,
+			maxInFlight: 3,
+		},
+		{
+			name:        "global concurrency",
+			docs:        200,
+			fetch:       &config.FetchConfig{PageSize: 10, Concurrency: 2, IndexConcurrency: 8},
+			pages:       20,
+			maxInFlight: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := meilitest.New()
+			defer srv.Close()
+			srv.AddIndex("movies", newTestDocs(tt.docs), nil)
+			srv.SetLatency(5 * time.Millisecond)
+
+			general, sitemaps := newTestConfig(srv.URL)
+			general.Fetch = tt.fetch
+			sitemaps["movies"].Fetch = tt.index
+
+			g, err := New(context.Background(), t.TempDir(), general, logger.DefaultLogger, sitemaps, WithOnce())
+			require.NoError(t, err)
+
+			docs, err := g.fetchIndexDocuments(context.Background(), "movies", sitemaps["movies"])
+			require.NoError(t, err)
+			require.Len(t, docs, tt.docs)
+
+			for i, doc := range docs {
+				require.EqualValues(t, i+1, doc["id"], "documents must keep order of index")
+			}
+
+			assert.Equal(t, tt.pages, srv.Hits("GET /indexes/{uid}/documents"))
+			assert.Equal(t, tt.maxInFlight, srv.MaxInFlight())
+		})
+	}
+}
+
+func TestFetchFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		docs   int
+		hits   string
+	}{
+		// empty filter is not sent, documents are listed without fetch route of filters.
+		{name: "empty filter", docs: 25, hits: "GET /indexes/{uid}/documents"},
+		{name: "filter", filter: "id > 7", docs: 18, hits: "POST /indexes/{uid}/documents/fetch"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := meilitest.New()
+			defer srv.Close()
+			srv.AddIndex("movies", newTestDocs(25), map[string]any{"filterableAttributes": []string{"id"}})
+
+			general, sitemaps := newTestConfig(srv.URL)
+			sitemaps["movies"].Filter = tt.filter
+			sitemaps["movies"].Fetch = &config.IndexFetch{PageSize: 4}
+
+			g, err := New(context.Background(), t.TempDir(), general, logger.DefaultLogger, sitemaps, WithOnce())
+			require.NoError(t, err)
+
+			docs, err := g.fetchIndexDocuments(context.Background(), "movies", sitemaps["movies"])
+			require.NoError(t, err)
+			require.Len(t, docs, tt.docs)
+
+			// pages are fetched by offset of page size, no document is fetched twice or skipped.
+			first := 25 - tt.docs + 1
+			for i, doc := range docs {
+				require.EqualValues(t, first+i, doc["id"])
+			}
+
+			assert.Equal(t, (tt.docs+3)/4, srv.Hits(tt.hits))
+		})
+	}
+}
+
+func TestFetchRateLimit(t *testing.T) {
+	srv := meilitest.New()
+	defer srv.Close()
+	srv.AddIndex("movies", newTestDocs(100), nil)
+
+	general, sitemaps := newTestConfig(srv.URL)
+	general.Fetch = &config.FetchConfig{PageSize: 10, RateLimit: 100}
+
+	g, err := New(context.Background(), t.TempDir(), general, logger.DefaultLogger, sitemaps, WithOnce())
+	require.NoError(t, err)
+
+	start := time.Now()
+	docs, err := g.fetchIndexDocuments(context.Background(), "movies", sitemaps["movies"])
+	require.NoError(t, err)
+	assert.Len(t, docs, 100)
+
+	// 10 pages with 100 requests per second, first request is not delayed.
+	assert.GreaterOrEqual(t, time.Since(start), 85*time.Millisecond)
+}
+
+func TestFetchFailedPage(t *testing.T) {
+	srv := meilitest.New()
+	defer srv.Close()
+	srv.AddIndex("movies", newTestDocs(100), nil)
+
+	general, sitemaps := newTestConfig(srv.URL)
+	general.Fetch = &config.FetchConfig{PageSize: 10}
+	once := 1
+	general.MeiliSearch.Retry = &config.RetryConfig{MaxAttempts: &once}
+
+	g, err := New(context.Background(), t.TempDir(), general, logger.DefaultLogger, sitemaps, WithOnce())
+	require.NoError(t, err)
+
+	srv.FailNext("GET /indexes/{uid}/documents", 3)
+	_, err = g.fetchIndexDocuments(context.Background(), "movies", sitemaps["movies"])
+	assert.Error(t, err)
+}
+
+func TestFetchFields(t *testing.T) {
+	tests := []struct {
+		name  string
+		index *config.IndexFetch
+		want  []string
+	}{
+		{name: "field map attributes", want: []string{"created_at", "id"}},
+		{name: "override", index: &config.IndexFetch{Fields: []string{"id", "title"}}, want: []string{"id", "title"}},
+		{name: "whole documents", index: &config.IndexFetch{Fields: []string{"*"}}, want: []string{"created_at", "id", "title"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := meilitest.New()
+			defer srv.Close()
+
+			docs := newTestDocs(20)
+			for _, doc := range docs {
+				doc["title"] = "movie"
+			}
+			srv.AddIndex("movies", docs, nil)
+
+			general, sitemaps := newTestConfig(srv.URL)
+			sitemaps["movies"].Fetch = tt.index
+
+			g, err := New(context.Background(), t.TempDir(), general, logger.DefaultLogger, sitemaps, WithOnce())
+			require.NoError(t, err)
+
+			res, err := g.fetchIndexDocuments(context.Background(), "movies", sitemaps["movies"])
+			require.NoError(t, err)
+			require.Len(t, res, 20)
+
+			for _, doc := range res {
+				assert.ElementsMatch(t, tt.want, slices.Collect(maps.Keys(doc)))
+			}
+		})
+	}
+}
+
+func TestFetchByCursor(t *testing.T) {
+	tests := []struct {
+		name      string
+		docs      int
+		filter    string
+		cursorKey string
+		pageSize  int
+		want      int
+		pages     int
+		wantErr   bool
+	}{
+		{name: "empty index", docs: 0, want: 0, pages: 1},
+		{name: "partial last page", docs: 25, want: 25, pages: 4},
+		{name: "full last page", docs: 20, want: 20, pages: 3},
+		{name: "with filter", docs: 25, filter: "id <= 15", want: 15, pages: 3},
+		{name: "string key", docs: 25, cursorKey: "slug", want: 25, pages: 4},
+		{name: "not sortable", docs: 25, cursorKey: "created_at", wantErr: true},
+		// search returns at most max total hits whatever limit is.
+		{name: "page size over max total hits", docs: 1500, pageSize: 2000, want: 1500, pages: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := meilitest.New()
+			defer srv.Close()
+
+			docs := newTestDocs(tt.docs)
+			for _, doc := range docs {
+				doc["slug"] = fmt.Sprintf("movie-%03d", doc["id"])
+			}
+			// index order differs from cursor order.
+			slices.Reverse(docs)
+
+			srv.AddIndex("movies", docs, map[string]any{
+				"displayedAttributes":  []string{"*"},
+				"filterableAttributes": []string{"id", "slug", "created_at"},
+				"sortableAttributes":   []string{"id", "slug"},
+			})
+
+			general, sitemaps := newTestConfig(srv.URL)
+			once := 1
+			general.MeiliSearch.Retry = &config.RetryConfig{MaxAttempts: &once}
+			sitemaps["movies"].Filter = tt.filter
+			sitemaps["movies"].Fetch = &config.IndexFetch{
+				PageSize:  cmp.Or(tt.pageSize, 10),
+				Strategy:  config.FetchCursor,
+				CursorKey: tt.cursorKey,
+			}
+
+			g, err := New(context.Background(), t.TempDir(), general, logger.DefaultLogger, sitemaps, WithOnce())
+			require.NoError(t, err)
+
+			res, err := g.fetchIndexDocuments(context.Background(), "movies", sitemaps["movies"])
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, res, tt.want)
+
+			for i, doc := range res {
+				require.EqualValues(t, i+1, doc["id"], "documents must be sorted by cursor key")
+			}
+
+			assert.Equal(t, tt.pages, srv.Hits("POST /indexes/{uid}/search"))
+			assert.Zero(t, srv.Hits("GET /indexes/{uid}/documents"))
+		})
+	}
+}
+
+func TestCursorFilter(t *testing.T) {
+	tests := []struct {
+		val  interface{}
+		want string
+	}{
+		{val: float64(42), want: `id > 42`},
+		{val: "movie-042", want: `id > "movie-042"`},
+		{val: `say "hi"`, want: `id > "say \"hi\""`},
+		{val: `C:\movies\`, want: `id > "C:\\movies\\"`},
+	}
+
+	for _, tt := range tests {
+		got, err := cursorFilter("id", tt.val)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got)
+	}
+
+	_, err := cursorFilter("id", true)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
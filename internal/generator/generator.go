@@ -1,50 +1,85 @@
 package generator
 
 import (
+	"bytes"
 	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/Ja7ad/meilisitemap/internal/admin"
 	"github.com/Ja7ad/meilisitemap/internal/logger"
+	"github.com/Ja7ad/meilisitemap/internal/metrics"
+	"github.com/Ja7ad/meilisitemap/internal/preflight"
+	"github.com/Ja7ad/meilisitemap/internal/report"
+	"github.com/Ja7ad/meilisitemap/internal/retry"
 	"github.com/Ja7ad/meilisitemap/internal/sched"
 	"github.com/Ja7ad/meilisitemap/internal/server"
 	"github.com/Ja7ad/meilisitemap/internal/sitemap"
+	"github.com/Ja7ad/meilisitemap/internal/tracing"
+	"github.com/Ja7ad/meilisitemap/internal/validator"
 	"github.com/meilisearch/meilisearch-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
 )
 
 const (
 	_standardXmlns = "http://www.sitemaps.org/schemas/sitemap/0.9"
 
-	_defaultWaitInterval   = 5 * time.Second
-	_defaultHitSizePerPage = 100
-	_dateLayout            = "2006-01-02"
+	_dateLayout        = "2006-01-02"
+	_maxLoggedProblems = 20
+	// _drainTimeout of running generations at shutdown.
+	_drainTimeout = 30 * time.Second
 )
 
 type Sitemap struct {
 	baseIndexURL     string
 	storePath        string
+	stateDir         string // stateDir keeps urls published by previous runs out of store
 	indexsitemapPath string
 	fileName         string
 	prefix           string
 	stylesheet       config.Stylesheet
+	indexStylesheet  string // indexStylesheet is href of stylesheet of sitemap index
+	feedStylesheet   string // feedStylesheet is href of stylesheet of rss and atom feeds
+	txtIndex         bool
+	txtFiles         map[string][]string // txtFiles are text sitemaps of indexes listed by text index
 	pprof            *config.PprofConfig
 	meili            meilisearch.ServiceManager
 	sitemaps         map[string]*config.SitemapConfig
 	logger           logger.Logger
 	wg               sync.WaitGroup
 	mu               sync.Mutex
+	general          *config.GeneralConfig
+	robots           *config.RobotsConfig
 	sched            *sched.Sched
+	schedules        map[string]sched.Schedule
 	ctx              context.Context
 	cancelFunc       context.CancelFunc
 	server           *server.Server
 	sm               *sitemap.Sitemap
+	report           *report.Recorder
+	retry            *retry.Policy
+	fetch            fetchOptions
+	fetchSem         chan struct{}
+	limiter          *rate.Limiter
+	lastSuccess      map[string]time.Time
+	staleFactor      int
+	sets             []string
+	once             bool
+	dryRun           io.Writer
+	dryRunSamples    int
+	validateMode     config.ValidateMode
 }
 
 func New(
@@ -53,112 +88,210 @@ func New(
 	general *config.GeneralConfig,
 	logger logger.Logger,
 	sitemaps map[string]*config.SitemapConfig,
+	opts ...Option,
 ) (*Sitemap, error) {
 	s := new(Sitemap)
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.general = general
 	s.baseIndexURL = general.BaseIndexURL
 	s.storePath = storePath
+	s.stateDir = general.StatePath
+	if s.stateDir == "" {
+		s.stateDir = filepath.Clean(storePath) + _stateSuffix
+	}
 	s.indexsitemapPath = general.IndexSitemapPath
 	s.fileName = general.FileName
 	s.prefix = general.Prefix
 	s.stylesheet = general.Stylesheet
+	s.robots = general.Robots
+	s.txtIndex = general.TxtIndex
+	s.validateMode = general.ValidateOutput
 	s.logger = logger
 	s.ctx, s.cancelFunc = context.WithCancel(ctx)
 	s.sched = sched.New(ctx, s.logger)
-	s.sm = sitemap.New(s.stylesheet, sitemaps, s.logger)
+	s.lastSuccess = make(map[string]time.Time)
+	s.txtFiles = make(map[string][]string)
+
+	if s.dryRun == nil {
+		s.report = report.NewRecorder(storePath)
+
+		if _, err := os.Stat(filepath.Join(s.storePath, s.indexsitemapPath)); os.IsNotExist(err) {
+			if err := os.Mkdir(filepath.Join(s.storePath, s.indexsitemapPath), 0o777); err != nil {
+				return nil, err
+			}
+		} else if err != nil {
+			return nil, err
+		}
+	}
 
-	if _, err := os.Stat(filepath.Join(s.storePath, s.indexsitemapPath)); os.IsNotExist(err) {
-		if err := os.Mkdir(filepath.Join(s.storePath, s.indexsitemapPath), 0o777); err != nil {
+	if general.Serve != nil && general.Serve.Enable && !s.once {
+		srv, err := server.New(general.Serve, storePath)
+		if err != nil {
 			return nil, err
 		}
-	} else if err != nil {
+
+		s.server = srv
+		s.server.HandlePrivate("GET /api/report", report.Handler(storePath))
+		s.server.SetReadiness(s.readiness)
+		s.server.HandlePrivate("GET /api/jobs", s.sched.Handler())
+
+		if a := general.Serve.Admin; a != nil && a.Enabled {
+			// token of admin is ignored when private routes are protected by serve auth.
+			token := a.Token
+			if general.Serve.Auth != nil {
+				token = ""
+			}
+			s.server.HandlePrivate(admin.Prefix, admin.Handler(token, &manager{s}))
+		}
+
+		s.staleFactor = general.Serve.StaleFactor
+		if s.staleFactor <= 0 {
+			s.staleFactor = config.DefaultStaleFactor
+		}
+	}
+
+	stylesheet, err := s.stylesheetLink(s.stylesheet, _sitemapStylesheet)
+	if err != nil {
 		return nil, err
 	}
 
-	if general.Serve != nil && general.Serve.Enable {
-		s.server = server.New(general.Serve, storePath)
+	if s.indexStylesheet, err = s.stylesheetLink(general.IndexStylesheet, _indexStylesheet); err != nil {
+		return nil, err
 	}
 
-reconnect:
-	client := meilisearch.New(general.MeiliSearch.Host, meilisearch.WithAPIKey(general.MeiliSearch.APIKey))
+	if s.feedStylesheet, err = s.stylesheetLink(general.FeedStylesheet, _feedStylesheet); err != nil {
+		return nil, err
+	}
+	s.sm = sitemap.New(stylesheet, sitemaps, s.logger)
 
-	if !client.IsHealthy() {
-		s.logger.Warn("failed connecting to Meilisearch, try connect...")
-		t := time.NewTicker(_defaultWaitInterval)
-		defer t.Stop()
+	s.setupFetch(general.Fetch)
 
-		for {
-			<-t.C
-			goto reconnect
-		}
+	s.retry = retry.NewPolicy(general.MeiliSearch.Retry)
+	s.retry.Retryable = isRetryable
+	s.retry.OnRetry = func(attempt int, err error, wait time.Duration) {
+		s.logger.Warn("meilisearch request failed, retrying",
+			"attempt", attempt, "wait", wait.String(), "err", err.Error())
+	}
+
+	s.meili = meilisearch.New(general.MeiliSearch.Host,
+		meilisearch.WithAPIKey(general.MeiliSearch.APIKey),
+		meilisearch.WithCustomClient(&http.Client{Transport: tracing.Transport(nil)}),
+	)
+
+	if err := s.connect(ctx, general.MeiliSearch.Retry); err != nil {
+		return nil, fmt.Errorf("failed connecting to Meilisearch: %w", err)
 	}
 
 	s.logger.Info("successfully connected to Meilisearch")
 
-	s.meili = client
 	s.sitemaps = sitemaps
+	s.schedules = make(map[string]sched.Schedule)
+
+	for idx, sm := range sitemaps {
+		if sm.LiveUpdate == nil || !sm.LiveUpdate.Enabled || sm.LiveUpdate.Mode == config.LiveTasks {
+			continue
+		}
+
+		schedule, err := liveSchedule(sm)
+		if err != nil {
+			return nil, fmt.Errorf("invalid live_update schedule of index %s: %w", idx, err)
+		}
+		s.schedules[idx] = schedule
+	}
 
 	return s, nil
 }
 
+// connect wait until Meilisearch is healthy, by default it retries until ctx done.
+func (s *Sitemap) connect(ctx context.Context, cfg *config.RetryConfig) error {
+	startup := *s.retry
+	startup.Breaker = nil
+	startup.MaxAttempts = 0
+	if cfg != nil {
+		startup.MaxAttempts = cfg.StartupAttempts
+	}
+	startup.OnRetry = func(attempt int, err error, wait time.Duration) {
+		s.logger.Warn("failed connecting to Meilisearch, try connect...",
+			"attempt", attempt, "wait", wait.String(), "err", err.Error())
+	}
+
+	return startup.Do(ctx, func(ctx context.Context) error {
+		health, err := s.meili.HealthWithContext(ctx)
+		if err != nil {
+			return err
+		}
+		if health.Status != "available" {
+			return fmt.Errorf("meilisearch status is %s", health.Status)
+		}
+		return nil
+	})
+}
+
 func (s *Sitemap) Start() error {
 	doneCh := make(chan struct{})
-	sets := make([]string, 0)
 	isLive := false
 
-	for idx, sm := range s.sitemaps {
-		if err := s.existsIndex(idx); err != nil {
-			return err
-		}
+	if err := s.preflight(); err != nil {
+		return err
+	}
 
+	if s.once {
+		return s.generateAll()
+	}
+
+	for idx, sm := range s.sitemaps {
 		s.wg.Add(1)
 		go func() {
 			defer s.wg.Done()
 
-			doFunc := func() {
-				s.logger.Info("started fetching documents", "index", idx)
-				results, err := s.fetchIndexDocuments(idx, sm.Filter)
-				if err != nil {
-					s.logger.Error("failed to fetch documents index", "index", idx, "err", err.Error())
-					return
-				}
-
-				b, err := s.sm.CreateSitemap(idx, results)
-				if err != nil {
-					s.logger.Error("failed to create sitemap for index",
-						"index", idx, "err", err.Error())
-					return
-				}
-
-				sAddr, err := s.saveSitemap(b, idx, sm)
-				if err != nil {
-					s.logger.Fatal("failed to save sitemap", "index", idx, "err", err.Error())
-				}
+			run := func(ctx context.Context) error {
+				return s.generate(ctx, idx, sm)
+			}
 
-				if !existsItem(sets, sAddr) {
-					sets = append(sets, sAddr)
+			var opts []sched.JobOption
+			schedule := sched.Manual
 
-					if err := s.createSitemapIndex(sets); err != nil {
-						log.Fatal("failed to create sitemap.xml", "err", err.Error())
+			switch {
+			case sm.LiveUpdate == nil || !sm.LiveUpdate.Enabled:
+				// sitemap generated once is a job while serving, so it can be run by admin api.
+				if s.server == nil {
+					if err := run(s.ctx); err != nil {
+						s.logger.Error("failed to generate sitemap", "index", idx, "err", err.Error())
 					}
+					return
 				}
+				opts = append(opts, sched.WithRunAtStart())
+			case sm.LiveUpdate.Mode == config.LiveTasks:
+				s.mu.Lock()
+				isLive = true
+				s.mu.Unlock()
 
-				s.logger.Info("created sitemap for index", "index", idx)
-			}
-
-			if sm.LiveUpdate != nil && sm.LiveUpdate.Enabled {
+				opts = append(opts, sched.WithOverlap(sm.LiveUpdate.Overlap), sched.WithRunAtStart())
+				go s.watchTasks(idx, sm, func() { _ = s.sched.Trigger(idx) })
+			default:
 				s.mu.Lock()
 				isLive = true
 				s.mu.Unlock()
-				s.sched.AddJob(doFunc, time.Duration(sm.LiveUpdate.Interval)*time.Second)
-			} else {
-				doFunc()
+
+				schedule = s.schedules[idx]
+				opts = append(opts, sched.WithOverlap(sm.LiveUpdate.Overlap), sched.WithJitter(sm.LiveUpdate.Jitter))
+				if sm.LiveUpdate.RunAtStart {
+					opts = append(opts, sched.WithRunAtStart())
+				}
+			}
+
+			if err := s.sched.Add(idx, run, schedule, opts...); err != nil {
+				s.logger.Error("failed to schedule sitemap", "index", idx, "err", err.Error())
 			}
 		}()
 	}
 
 	if s.server != nil {
 		go func() {
-			s.logger.Info("sitemaps served", "addr", "http://"+s.server.Addr())
+			s.logger.Info("sitemaps served", "addr", s.server.URL(), "admin_addr", s.server.AdminAddr())
 			s.server.Start()
 			for {
 				select {
@@ -191,12 +324,321 @@ func (s *Sitemap) Start() error {
 
 	<-doneCh
 
+	if s.sched.Len() != 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), _drainTimeout)
+		defer cancel()
+
+		if err := s.sched.Stop(ctx); err != nil {
+			s.logger.Warn("sitemap generation is still running at shutdown", "err", err.Error())
+		}
+	}
+
 	return nil
 }
 
-func (s *Sitemap) existsIndex(idx string) error {
-	_, err := s.meili.GetIndex(idx)
-	return err
+// generateAll generate every sitemap a single time and returns errors of all failed indexes.
+func (s *Sitemap) generateAll() error {
+	errs := make([]error, 0)
+
+	for idx, sm := range s.sitemaps {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+
+			if err := s.generate(s.ctx, idx, sm); err != nil {
+				s.logger.Error("failed to generate sitemap", "index", idx, "err", err.Error())
+				s.mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", idx, err))
+				s.mu.Unlock()
+			}
+		}()
+	}
+
+	s.wg.Wait()
+	s.cancelFunc()
+	s.logger.Info("completed create sitemap")
+
+	return errors.Join(errs...)
+}
+
+// generate fetch documents of index, build sitemap and save it to store and sitemap index.
+// In dry run mode nothing saved and only stats and sample urls printed.
+func (s *Sitemap) generate(ctx context.Context, idx string, sm *config.SitemapConfig) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "generator.generate", trace.WithAttributes(attribute.String("index", idx)))
+	ir := report.NewIndex(idx, filepath.Join(s.indexsitemapPath, s.sitemapFileName(idx, sm)))
+	defer func() {
+		s.record(ir, err)
+		tracing.End(span, err)
+	}()
+
+	if src := sm.SourceIndex(idx); src != idx {
+		ir.Source = src
+	}
+
+	ir.TaskUID, err = s.snapshot(ctx, idx, sm)
+	if err != nil {
+		return fmt.Errorf("failed to take snapshot of index: %w", err)
+	}
+
+	s.logger.Info("started fetching documents", "index", idx, "source", sm.SourceIndex(idx))
+	results, err := s.fetchIndexDocuments(ctx, idx, sm)
+	if err != nil {
+		return fmt.Errorf("failed to fetch documents index: %w", err)
+	}
+
+	urlSet, stats := s.sm.BuildURLSet(ctx, idx, results)
+	ir.Documents = stats.Documents
+	ir.URLs = stats.URLs
+	ir.Skipped = stats.TotalSkipped()
+	ir.SkipReasons = stats.Skipped
+	ir.Duplicates = stats.Duplicates
+
+	prev, err := s.loadState(idx)
+	if err != nil {
+		return fmt.Errorf("failed to load previous urls: %w", err)
+	}
+
+	if err := checkShrink(prev, stats.URLs, sm.Retention); err != nil {
+		return err
+	}
+
+	state := retain(prev, urlSet, sm.Retention, ir)
+
+	b, err := s.sm.Encode(ctx, idx, urlSet)
+	if err != nil {
+		return fmt.Errorf("failed to create sitemap for index: %w", err)
+	}
+
+	if err := s.validateOutput(s.sitemapFileName(idx, sm), b, sm.BaseAddress); err != nil {
+		return err
+	}
+
+	if s.dryRun != nil {
+		// size of sitemap would be written.
+		ir.Bytes = len(b)
+		s.printDryRun(ir, urlSet)
+		return nil
+	}
+
+	ir.Changed = s.contentChanged(idx, sm, b)
+
+	sAddr, err := s.saveSitemap(ctx, b, idx, sm)
+	if err != nil {
+		return fmt.Errorf("failed to save sitemap: %w", err)
+	}
+	ir.Bytes = len(b)
+
+	if err := s.saveState(idx, state); err != nil {
+		return fmt.Errorf("failed to save urls of sitemap: %w", err)
+	}
+
+	if sm.Feed != nil && len(sm.Feed.Formats) != 0 {
+		if err := s.writeFeeds(ctx, idx, sm, results); err != nil {
+			return fmt.Errorf("failed to write feeds: %w", err)
+		}
+	}
+
+	var txtFiles []string
+	if sm.Txt {
+		if txtFiles, err = s.writeTxt(ctx, idx, sm, urlSet); err != nil {
+			return fmt.Errorf("failed to write text sitemap: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !existsItem(s.sets, sAddr) {
+		s.sets = append(s.sets, sAddr)
+
+		if err := s.createSitemapIndex(s.sets); err != nil {
+			return fmt.Errorf("failed to create sitemap index: %w", err)
+		}
+	}
+
+	if sm.Txt && s.txtIndex {
+		s.txtFiles[idx] = txtFiles
+		if err := s.createTxtIndex(); err != nil {
+			return fmt.Errorf("failed to create text index: %w", err)
+		}
+	}
+
+	s.logger.Info("created sitemap for index", "index", idx, "urls", ir.URLs, "skipped", ir.Skipped,
+		"added", ir.Added, "removed", ir.Removed, "retained", ir.Retained, "changed", ir.Changed)
+
+	return nil
+}
+
+// record add run of index to metrics and report of store, nothing kept in dry run.
+func (s *Sitemap) record(ir *report.Index, err error) {
+	if s.report == nil {
+		return
+	}
+
+	ir.Finish(err)
+
+	metrics.GenerationDuration.WithLabelValues(ir.Index).Observe(time.Since(ir.StartedAt).Seconds())
+	metrics.DocumentsProcessed.WithLabelValues(ir.Index).Add(float64(ir.Documents))
+	for reason, n := range ir.SkipReasons {
+		metrics.DocumentsSkipped.WithLabelValues(ir.Index, reason).Add(float64(n))
+	}
+
+	if err != nil {
+		metrics.GenerationFailures.WithLabelValues(ir.Index).Inc()
+	} else {
+		metrics.LastSuccess.WithLabelValues(ir.Index).SetToCurrentTime()
+		metrics.SetFileSize(ir.File, ir.Bytes)
+
+		s.mu.Lock()
+		s.lastSuccess[ir.Index] = time.Now()
+		s.mu.Unlock()
+	}
+
+	if err := s.report.Record(ir); err != nil {
+		s.logger.Error("failed to write report", "index", ir.Index, "err", err.Error())
+	}
+}
+
+// readiness report ready when meilisearch is reachable and every sitemap generated at least once,
+// sitemaps with live update must not be older than stale factor × period of schedule.
+func (s *Sitemap) readiness(ctx context.Context) *server.Readiness {
+	res := &server.Readiness{
+		Ready:       true,
+		Meilisearch: &server.Status{Ready: true},
+		Indexes:     make([]*server.IndexStatus, 0, len(s.sitemaps)),
+	}
+
+	if _, err := s.meili.HealthWithContext(ctx); err != nil {
+		res.Ready = false
+		res.Meilisearch = &server.Status{Message: err.Error()}
+	}
+
+	names := make([]string, 0, len(s.sitemaps))
+	for idx := range s.sitemaps {
+		names = append(names, idx)
+	}
+	sort.Strings(names)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, idx := range names {
+		st := &server.IndexStatus{Index: idx, Ready: true}
+		res.Indexes = append(res.Indexes, st)
+
+		last, ok := s.lastSuccess[idx]
+		if !ok {
+			st.Ready = false
+			st.Message = "sitemap not generated yet"
+			res.Ready = false
+			continue
+		}
+		st.LastSuccess = &last
+
+		sm := s.sitemaps[idx]
+		// sitemap updated by tasks is not stale while index is not changed.
+		if sm.LiveUpdate == nil || !sm.LiveUpdate.Enabled || sm.LiveUpdate.Mode == config.LiveTasks {
+			continue
+		}
+
+		// stale after stale factor runs of schedule are missed since last success.
+		deadline := sched.Deadline(s.schedules[idx], last, s.staleFactor).Add(sm.LiveUpdate.Jitter)
+		st.MaxAge = deadline.Sub(last).String()
+
+		if time.Now().After(deadline) {
+			st.Ready = false
+			st.Message = "sitemap is stale"
+			res.Ready = false
+		}
+	}
+
+	return res
+}
+
+// contentChanged compare sitemap with current file of index in store.
+func (s *Sitemap) contentChanged(idx string, sm *config.SitemapConfig, data []byte) bool {
+	prev, err := os.ReadFile(filepath.Join(s.storePath, s.indexsitemapPath, s.sitemapFileName(idx, sm)))
+	if err != nil {
+		return true
+	}
+	return !bytes.Equal(prev, data)
+}
+
+func (s *Sitemap) printDryRun(ir *report.Index, urlSet *sitemap.URLSet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w := s.dryRun
+	_, _ = fmt.Fprintf(w, "index %s: documents=%d urls=%d skipped=%d duplicates=%d added=%d removed=%d retained=%d bytes=%d file=%s\n",
+		ir.Index, ir.Documents, ir.URLs, ir.Skipped, ir.Duplicates, ir.Added, ir.Removed, ir.Retained, ir.Bytes, ir.File)
+
+	reasons := make([]string, 0, len(ir.SkipReasons))
+	for reason := range ir.SkipReasons {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+
+	for _, reason := range reasons {
+		_, _ = fmt.Fprintf(w, "  skipped %s: %d\n", reason, ir.SkipReasons[reason])
+	}
+
+	for i, u := range urlSet.URLs {
+		if i == s.dryRunSamples {
+			break
+		}
+		_, _ = fmt.Fprintf(w, "  %s\n", u.Loc)
+	}
+}
+
+// validateOutput check generated file conform to sitemap protocol based on validate_output mode,
+// every loc of file must be on host of baseLoc.
+func (s *Sitemap) validateOutput(name string, data []byte, baseLoc string) error {
+	if s.validateMode == "" || s.validateMode == config.ValidateOff {
+		return nil
+	}
+
+	opts := validator.Options{}
+	if u, err := url.Parse(baseLoc); err == nil {
+		opts.Host = u.Host
+	}
+
+	res, err := validator.Validate(data, opts)
+	if err != nil {
+		return fmt.Errorf("failed to validate %s: %w", name, err)
+	}
+
+	for i, p := range res.Problems {
+		if i == _maxLoggedProblems {
+			s.logger.Warn("too many sitemap problems, rest are not logged",
+				"file", name, "total", len(res.Problems))
+			break
+		}
+		s.logger.Warn("sitemap does not conform to protocol", "file", name, "problem", p.String())
+	}
+
+	if s.validateMode == config.ValidateError {
+		return res.Err()
+	}
+
+	return nil
+}
+
+// preflight verify field maps against live indexes and fail fast on fatal mismatches.
+func (s *Sitemap) preflight() error {
+	report := preflight.Run(s.ctx, s.meili, s.sitemaps)
+
+	for _, idx := range report.Indexes {
+		for _, c := range idx.Checks {
+			switch c.Level {
+			case preflight.LevelWarning:
+				s.logger.Warn("preflight warning", "index", idx.Index, "check", c.String())
+			case preflight.LevelFatal:
+				s.logger.Error("preflight failed", "index", idx.Index, "check", c.String())
+			}
+		}
+	}
+
+	return report.Err()
 }
 
 func (s *Sitemap) createSitemapIndex(setsFilename []string) error {
@@ -209,15 +651,11 @@ func (s *Sitemap) createSitemapIndex(setsFilename []string) error {
 	for _, fn := range setsFilename {
 		smLoc := new(sitemap.SMLoc)
 
-		loc, err := url.JoinPath(s.baseIndexURL, s.indexsitemapPath, fn)
+		loc, err := url.JoinPath(s.baseLoc(), s.indexsitemapPath, fn)
 		if err != nil {
 			return err
 		}
 
-		if s.server != nil {
-			loc, err = url.JoinPath("http://"+s.server.Addr(), s.indexsitemapPath, fn)
-		}
-
 		smLoc.Loc = loc
 		smLoc.LastMod = now
 		sitemapIdx.Sitemaps = append(sitemapIdx.Sitemaps, smLoc)
@@ -228,96 +666,97 @@ func (s *Sitemap) createSitemapIndex(setsFilename []string) error {
 		return err
 	}
 
-	fileName := "sitemap"
-
-	if s.fileName != "" {
-		fileName = s.fileName
+	if err := s.validateOutput("sitemap index", xmlData, s.baseLoc()); err != nil {
+		return err
 	}
 
-	filePath := filepath.Join(s.storePath, fileName+".xml")
+	fileName := s.indexFileName()
+	filePath := filepath.Join(s.storePath, fileName)
 
-	file, err := os.Create(filePath)
-	if err != nil {
-		return fmt.Errorf("error creating file %s: %v", filePath, err)
+	xmlData = append(sitemap.Header(s.indexStylesheet), xmlData...)
+
+	if err := writeFileAtomic(filePath, xmlData); err != nil {
+		return fmt.Errorf("error writing to file %s: %w", filePath, err)
 	}
-	defer func() {
-		_ = file.Close()
-	}()
 
-	if _, err := file.Write(xmlData); err != nil {
-		return fmt.Errorf("error writing to file %s: %v", filePath, err)
+	metrics.SetFileSize(fileName, len(xmlData))
+
+	if s.robots != nil && s.robots.Enabled {
+		return s.writeRobots()
 	}
 
 	return nil
 }
 
-func (s *Sitemap) fetchIndexDocuments(index, filter string) ([]map[string]interface{}, error) {
-	results := make([]map[string]interface{}, 0)
+// indexFileName returns file name of root sitemap index in store.
+func (s *Sitemap) indexFileName() string {
+	if s.fileName != "" {
+		return s.fileName + ".xml"
+	}
+	return "sitemap.xml"
+}
 
-	resp := new(meilisearch.DocumentsResult)
-	if err := s.meili.Index(index).GetDocuments(&meilisearch.DocumentsQuery{
-		Limit:  _defaultHitSizePerPage,
-		Filter: filter,
-	}, resp); err != nil {
-		return nil, err
+// baseLoc returns base url of sitemaps in store, address of server when it's serving store.
+func (s *Sitemap) baseLoc() string {
+	if s.server != nil {
+		return s.server.URL()
 	}
+	return s.baseIndexURL
+}
 
-	results = append(results, resp.Results...)
+func (s *Sitemap) saveSitemap(ctx context.Context, data []byte, indexName string, cfg *config.SitemapConfig) (_ string, err error) {
+	fileName := s.sitemapFileName(indexName, cfg)
 
-	if resp.Total > _defaultHitSizePerPage {
-		totalOffset := (resp.Total + _defaultHitSizePerPage - 1) / _defaultHitSizePerPage
-		for i := int64(1); i < totalOffset; i++ {
-			var nextResp meilisearch.DocumentsResult
-			if err := s.meili.Index(index).GetDocuments(&meilisearch.DocumentsQuery{
-				Offset: i,
-				Limit:  _defaultHitSizePerPage,
-				Filter: filter,
-			}, &nextResp); err != nil {
-				return nil, err
-			}
+	_, span := tracing.Tracer().Start(ctx, "generator.saveSitemap", trace.WithAttributes(
+		attribute.String("file", fileName),
+		attribute.Int("bytes", len(data)),
+	))
+	defer func() {
+		tracing.End(span, err)
+	}()
 
-			if len(nextResp.Results) == 0 {
-				break
-			}
+	filePath := filepath.Join(s.storePath, s.indexsitemapPath, fileName)
 
-			results = append(results, nextResp.Results...)
-		}
+	if err := writeFileAtomic(filePath, data); err != nil {
+		return "", fmt.Errorf("error writing to file %s: %w", filePath, err)
 	}
 
-	return results, nil
+	return fileName, nil
 }
 
-func (s *Sitemap) saveSitemap(data []byte, indexName string, cfg *config.SitemapConfig) (string, error) {
-	fileName := indexName
-	if cfg.SitemapFileName != "" {
-		fileName = cfg.SitemapFileName
-	}
+// writeFileAtomic write b to temporary file next to path and rename it to path, so readers never
+// see a partially written file.
+func writeFileAtomic(path string, b []byte) error {
+	tmp := path + ".tmp"
 
-	if s.prefix != "" {
-		fileName = s.prefix + fileName
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		_ = os.Remove(tmp)
+		return err
 	}
 
+	return os.Rename(tmp, path)
+}
+
+func (s *Sitemap) sitemapFileName(indexName string, cfg *config.SitemapConfig) string {
+	fileName := s.baseFileName(indexName, cfg)
+
 	if cfg.Compress {
 		fileName += ".xml.gz"
 	} else {
 		fileName += ".xml"
 	}
 
-	filePath := filepath.Join(s.storePath, s.indexsitemapPath, fileName)
-
-	file, err := os.Create(filePath)
-	if err != nil {
-		return "", fmt.Errorf("error creating file %s: %v", filePath, err)
-	}
-	defer func() {
-		_ = file.Close()
-	}()
+	return fileName
+}
 
-	if _, err := file.Write(data); err != nil {
-		return "", fmt.Errorf("error writing to file %s: %v", filePath, err)
+// baseFileName of index files without extension.
+func (s *Sitemap) baseFileName(indexName string, cfg *config.SitemapConfig) string {
+	fileName := indexName
+	if cfg.SitemapFileName != "" {
+		fileName = cfg.SitemapFileName
 	}
 
-	return fileName, nil
+	return s.prefix + fileName
 }
 
 func existsItem(items []string, item string) bool {
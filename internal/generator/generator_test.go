@@ -0,0 +1,330 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/Ja7ad/meilisitemap/internal/logger"
+	"github.com/Ja7ad/meilisitemap/internal/meilitest"
+	"github.com/Ja7ad/meilisitemap/internal/metrics"
+	"github.com/Ja7ad/meilisitemap/internal/report"
+	"github.com/Ja7ad/meilisitemap/internal/sched"
+	"github.com/Ja7ad/meilisitemap/internal/sitemap"
+	"github.com/Ja7ad/meilisitemap/internal/validator"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func newTestDocs(n int) []map[string]any {
+	docs := make([]map[string]any, 0, n)
+	for i := 1; i <= n; i++ {
+		docs = append(docs, map[string]any{
+			"id":         i,
+			"created_at": "2024-01-02T15:04:05Z",
+		})
+	}
+	return docs
+}
+
+func newTestConfig(host string) (*config.GeneralConfig, map[string]*config.SitemapConfig) {
+	general := &config.GeneralConfig{
+		BaseIndexURL:     "https://example.com",
+		IndexSitemapPath: "sitemaps",
+		MeiliSearch:      &config.MeiliSearchConfig{Host: host},
+		Serve:            &config.ServeConfig{Enable: true, Listen: "127.0.0.1:0"},
+	}
+
+	sitemaps := map[string]*config.SitemapConfig{
+		"movies": {
+			Sitemap:     true,
+			BaseAddress: "https://example.com/movies",
+			FieldMap: &config.FieldMapConfig{
+				UniqueField: "id",
+				LastMod:     "created_at",
+				ChangeFreq:  config.Daily,
+				Priority:    config.High,
+			},
+		},
+	}
+
+	return general, sitemaps
+}
+
+func TestGenerateOnce(t *testing.T) {
+	srv := meilitest.New()
+	defer srv.Close()
+	srv.AddIndex("movies", newTestDocs(250), nil)
+
+	store := t.TempDir()
+	general, sitemaps := newTestConfig(srv.URL)
+
+	g, err := New(context.Background(), store, general, logger.DefaultLogger, sitemaps, WithOnce())
+	require.NoError(t, err)
+	assert.Nil(t, g.server)
+
+	require.NoError(t, g.Start())
+
+	b, err := os.ReadFile(filepath.Join(store, "sitemaps", "movies.xml"))
+	require.NoError(t, err)
+	assert.Equal(t, 250, bytes.Count(b, []byte("<url>")))
+	assert.FileExists(t, filepath.Join(store, "sitemap.xml"))
+
+	assert.NotZero(t, testutil.ToFloat64(metrics.LastSuccess.WithLabelValues("movies")))
+	assert.Equal(t, float64(len(b)), testutil.ToFloat64(metrics.FileSize.WithLabelValues("sitemaps/movies.xml")))
+}
+
+func TestGenerateDryRun(t *testing.T) {
+	srv := meilitest.New()
+	defer srv.Close()
+	srv.AddIndex("movies", newTestDocs(3), nil)
+
+	store := filepath.Join(t.TempDir(), "store")
+	general, sitemaps := newTestConfig(srv.URL)
+
+	out := new(bytes.Buffer)
+	g, err := New(context.Background(), store, general, logger.DefaultLogger, sitemaps, WithDryRun(out, 2))
+	require.NoError(t, err)
+	require.NoError(t, g.Start())
+
+	assert.NoDirExists(t, store)
+	assert.Contains(t, out.String(), "index movies: documents=3 urls=3 skipped=0")
+	assert.Contains(t, out.String(), "  https://example.com/movies/1\n  https://example.com/movies/2\n")
+	assert.NotContains(t, out.String(), "https://example.com/movies/3")
+}
+
+func TestGeneratePreflightFailed(t *testing.T) {
+	srv := meilitest.New()
+	defer srv.Close()
+	srv.AddIndex("movies", newTestDocs(3), nil)
+
+	general, sitemaps := newTestConfig(srv.URL)
+	sitemaps["movies"].FieldMap.UniqueField = "slug"
+
+	g, err := New(context.Background(), t.TempDir(), general, logger.DefaultLogger, sitemaps, WithOnce())
+	require.NoError(t, err)
+	require.Error(t, g.Start())
+}
+
+func TestGenerateValidateOutput(t *testing.T) {
+	srv := meilitest.New()
+	defer srv.Close()
+
+	docs := newTestDocs(3)
+	for _, doc := range docs {
+		doc["poster"] = "poster.jpg"
+	}
+	srv.AddIndex("movies", docs, nil)
+
+	general, sitemaps := newTestConfig(srv.URL)
+	sitemaps["movies"].FieldMap.Image = &config.ImageConfig{Loc: "poster"}
+
+	general.ValidateOutput = config.ValidateWarn
+	g, err := New(context.Background(), t.TempDir(), general, logger.DefaultLogger, sitemaps, WithOnce())
+	require.NoError(t, err)
+	require.NoError(t, g.Start())
+
+	general.ValidateOutput = config.ValidateError
+	store := t.TempDir()
+	g, err = New(context.Background(), store, general, logger.DefaultLogger, sitemaps, WithOnce())
+	require.NoError(t, err)
+	require.ErrorIs(t, g.Start(), validator.ErrNotConform)
+
+	// nothing is written by rejected sitemap.
+	rep, err := report.Load(store)
+	require.NoError(t, err)
+	require.Len(t, rep.Indexes, 1)
+	assert.Zero(t, rep.Indexes[0].Bytes)
+	assert.NotEmpty(t, rep.Indexes[0].Error)
+}
+
+func TestGenerateReport(t *testing.T) {
+	srv := meilitest.New()
+	defer srv.Close()
+
+	docs := newTestDocs(3)
+	docs = append(docs,
+		map[string]any{"id": "1", "created_at": "2024-01-02T15:04:05Z"},
+		map[string]any{"id": "  ", "created_at": "2024-01-02T15:04:05Z"},
+	)
+	srv.AddIndex("movies", docs, nil)
+
+	store := t.TempDir()
+	general, sitemaps := newTestConfig(srv.URL)
+
+	for _, changed := range []bool{true, false} {
+		g, err := New(context.Background(), store, general, logger.DefaultLogger, sitemaps, WithOnce())
+		require.NoError(t, err)
+		require.NoError(t, g.Start())
+
+		rep, err := report.Load(store)
+		require.NoError(t, err)
+		require.Len(t, rep.Indexes, 1)
+
+		ir := rep.Indexes[0]
+		assert.Equal(t, "movies", ir.Index)
+		assert.Equal(t, filepath.Join("sitemaps", "movies.xml"), ir.File)
+		assert.Equal(t, 5, ir.Documents)
+		assert.Equal(t, 3, ir.URLs)
+		assert.Equal(t, 1, ir.Skipped)
+		assert.Equal(t, map[string]int{sitemap.SkipEmptySlug: 1}, ir.SkipReasons)
+		assert.Equal(t, 1, ir.Duplicates)
+		assert.NotZero(t, ir.Bytes)
+		assert.Equal(t, changed, ir.Changed)
+		assert.Empty(t, ir.Error)
+	}
+}
+
+func TestGenerateReadiness(t *testing.T) {
+	srv := meilitest.New()
+	defer srv.Close()
+	srv.AddIndex("movies", newTestDocs(3), nil)
+
+	general, sitemaps := newTestConfig(srv.URL)
+	sitemaps["movies"].LiveUpdate = &config.LiveConfig{Enabled: true, Interval: 60}
+
+	g, err := New(context.Background(), t.TempDir(), general, logger.DefaultLogger, sitemaps)
+	require.NoError(t, err)
+
+	res := g.readiness(context.Background())
+	assert.False(t, res.Ready)
+	assert.True(t, res.Meilisearch.Ready)
+	assert.Equal(t, "sitemap not generated yet", res.Indexes[0].Message)
+
+	require.NoError(t, g.generate(context.Background(), "movies", sitemaps["movies"]))
+
+	res = g.readiness(context.Background())
+	assert.True(t, res.Ready)
+	assert.Equal(t, "3m0s", res.Indexes[0].MaxAge)
+
+	g.lastSuccess["movies"] = time.Now().Add(-time.Hour)
+	res = g.readiness(context.Background())
+	assert.False(t, res.Ready)
+	assert.Equal(t, "sitemap is stale", res.Indexes[0].Message)
+
+	// weekend is not counted by weekdays only cron, stale after three missed runs.
+	weekdays, err := sched.Cron("0 3 * * 1-5", time.UTC)
+	require.NoError(t, err)
+	g.schedules["movies"] = weekdays
+	g.lastSuccess["movies"] = time.Date(2024, 1, 5, 3, 0, 0, 0, time.UTC)
+	res = g.readiness(context.Background())
+	assert.False(t, res.Ready)
+	assert.Equal(t, "120h0m0s", res.Indexes[0].MaxAge)
+
+	srv.SetHealthy(false)
+	g.lastSuccess["movies"] = time.Now()
+	res = g.readiness(context.Background())
+	assert.False(t, res.Ready)
+	assert.False(t, res.Meilisearch.Ready)
+	assert.True(t, res.Indexes[0].Ready)
+}
+
+func TestGenerateTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(tp)
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	srv := meilitest.New()
+	defer srv.Close()
+	srv.AddIndex("movies", newTestDocs(3), nil)
+
+	general, sitemaps := newTestConfig(srv.URL)
+	sitemaps["movies"].Compress = true
+
+	g, err := New(context.Background(), t.TempDir(), general, logger.DefaultLogger, sitemaps, WithOnce())
+	require.NoError(t, err)
+	require.NoError(t, g.Start())
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, s := range exporter.GetSpans().Snapshots() {
+		spans[s.Name()] = s
+	}
+
+	root := spans["generator.generate"]
+	require.NotNil(t, root)
+
+	for _, name := range []string{
+		"generator.fetchIndexDocuments",
+		"meilisearch.GetDocuments",
+		"sitemap.BuildURLSet",
+		"sitemap.Encode",
+		"sitemap.minify",
+		"sitemap.compress",
+		"generator.saveSitemap",
+	} {
+		s, ok := spans[name]
+		require.True(t, ok, name)
+		assert.Equal(t, root.SpanContext().TraceID(), s.SpanContext().TraceID(), name)
+	}
+}
+
+func TestGenerateRetryPage(t *testing.T) {
+	srv := meilitest.New()
+	defer srv.Close()
+	srv.AddIndex("movies", newTestDocs(150), nil)
+
+	store := t.TempDir()
+	general, sitemaps := newTestConfig(srv.URL)
+	general.MeiliSearch.Retry = &config.RetryConfig{InitialBackoff: time.Millisecond}
+
+	g, err := New(context.Background(), store, general, logger.DefaultLogger, sitemaps, WithOnce())
+	require.NoError(t, err)
+
+	pages := testutil.ToFloat64(metrics.FetchPages.WithLabelValues("movies"))
+
+	srv.FailNext("GET /indexes/{uid}/documents", 2)
+	require.NoError(t, g.generate(context.Background(), "movies", sitemaps["movies"]))
+
+	b, err := os.ReadFile(filepath.Join(store, "sitemaps", "movies.xml"))
+	require.NoError(t, err)
+	assert.Equal(t, 150, bytes.Count(b, []byte("<url>")))
+	assert.Equal(t, 4, srv.Hits("GET /indexes/{uid}/documents"))
+	// failed attempts are not pages.
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.FetchPages.WithLabelValues("movies"))-pages)
+}
+
+func TestNewCanceledWhileConnecting(t *testing.T) {
+	srv := meilitest.New()
+	defer srv.Close()
+	srv.SetHealthy(false)
+
+	general, sitemaps := newTestConfig(srv.URL)
+	general.MeiliSearch.Retry = &config.RetryConfig{InitialBackoff: 10 * time.Millisecond}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	_, err := New(ctx, t.TempDir(), general, logger.DefaultLogger, sitemaps, WithOnce())
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Greater(t, srv.Hits("GET /health"), 1)
+
+	general.MeiliSearch.Retry.StartupAttempts = 2
+	_, err = New(context.Background(), t.TempDir(), general, logger.DefaultLogger, sitemaps, WithOnce())
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, context.Canceled)
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sitemap.xml")
+
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0o644))
+	require.NoError(t, writeFileAtomic(path, []byte("new")))
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(b))
+	assert.NoFileExists(t, path+".tmp")
+
+	assert.Error(t, writeFileAtomic(filepath.Join(dir, "missing", "sitemap.xml"), nil))
+}
@@ -0,0 +1,23 @@
+package generator
+
+import "io"
+
+type Option func(s *Sitemap)
+
+// WithOnce generate every sitemap a single time and return from Start,
+// regardless of serve and live_update config.
+func WithOnce() Option {
+	return func(s *Sitemap) {
+		s.once = true
+	}
+}
+
+// WithDryRun fetch documents and build sitemaps without writing any file,
+// stats and first samples urls of every sitemap printed to w. Dry run implies WithOnce.
+func WithDryRun(w io.Writer, samples int) Option {
+	return func(s *Sitemap) {
+		s.once = true
+		s.dryRun = w
+		s.dryRunSamples = samples
+	}
+}
@@ -0,0 +1,153 @@
+package generator
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/Ja7ad/meilisitemap/internal/report"
+	"github.com/Ja7ad/meilisitemap/internal/sitemap"
+)
+
+// _stateSuffix of store path is default directory of urls published by previous run of every index.
+const _stateSuffix = ".state"
+
+var ErrShrinkExceeded = errors.New("sitemap shrinks more than max_shrink")
+
+// urlState is urls of index published by previous run.
+type urlState struct {
+	URLs    map[string]*sitemap.URL `json:"urls"`    // URLs published by loc
+	Removed map[string]time.Time    `json:"removed"` // Removed locs retained in sitemap by time of removal
+}
+
+// retain compare url set with previous run, urls of removed documents are kept in url set during
+// grace period and additions and removals are recorded in report. Returns state of url set.
+func retain(prev *urlState, set *sitemap.URLSet, retention *config.RetentionConfig, ir *report.Index) *urlState {
+	next := &urlState{
+		URLs:    make(map[string]*sitemap.URL, len(set.URLs)),
+		Removed: make(map[string]time.Time),
+	}
+
+	for _, u := range set.URLs {
+		next.URLs[u.Loc] = u
+		if _, ok := prev.URLs[u.Loc]; !ok {
+			ir.Added++
+		}
+	}
+
+	var grace time.Duration
+	if retention != nil {
+		grace = retention.GracePeriod
+	}
+
+	now := time.Now()
+	retained := make([]*sitemap.URL, 0)
+
+	for loc, u := range prev.URLs {
+		if _, ok := next.URLs[loc]; ok {
+			continue
+		}
+
+		removedAt, wasRemoved := prev.Removed[loc]
+		if !wasRemoved {
+			removedAt = now
+			ir.Removed++
+		}
+
+		if now.Sub(removedAt) < grace {
+			next.Removed[loc] = removedAt
+			retained = append(retained, u)
+		}
+	}
+
+	slices.SortFunc(retained, func(a, b *sitemap.URL) int {
+		return cmp.Compare(a.Loc, b.Loc)
+	})
+
+	for _, u := range retained {
+		next.URLs[u.Loc] = u
+	}
+
+	set.URLs = append(set.URLs, retained...)
+	ir.Retained = len(retained)
+
+	return next
+}
+
+// checkShrink returns error when urls of documents are fewer than previous run more than max shrink,
+// retained urls are not counted.
+func checkShrink(prev *urlState, urls int, retention *config.RetentionConfig) error {
+	if retention == nil || retention.MaxShrink == 0 {
+		return nil
+	}
+
+	prevURLs := len(prev.URLs) - len(prev.Removed)
+	if prevURLs == 0 || urls >= prevURLs {
+		return nil
+	}
+
+	shrink := float64(prevURLs-urls) / float64(prevURLs) * 100
+	if shrink > retention.MaxShrink {
+		return fmt.Errorf("%w, %d urls of %d in previous run (%.1f%%), max is %g%%",
+			ErrShrinkExceeded, urls, prevURLs, shrink, retention.MaxShrink)
+	}
+
+	return nil
+}
+
+// loadState read state of index, empty state if index is not published yet or state is
+// corrupted, run after corrupted state reports every url as added.
+func (s *Sitemap) loadState(idx string) (*urlState, error) {
+	state := &urlState{
+		URLs:    make(map[string]*sitemap.URL),
+		Removed: make(map[string]time.Time),
+	}
+
+	b, err := os.ReadFile(s.statePath(idx))
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, state); err != nil {
+		s.logger.Warn("invalid state of index, starting from empty state", "index", idx, "err", err.Error())
+		return &urlState{
+			URLs:    make(map[string]*sitemap.URL),
+			Removed: make(map[string]time.Time),
+		}, nil
+	}
+
+	return state, nil
+}
+
+// saveState write state of index, state replaced atomically.
+func (s *Sitemap) saveState(idx string, state *urlState) error {
+	path := s.statePath(idx)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(path, b); err != nil {
+		return fmt.Errorf("error writing state %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func (s *Sitemap) statePath(idx string) string {
+	return filepath.Join(s.stateDir, idx+".json")
+}
@@ -0,0 +1,145 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/Ja7ad/meilisitemap/internal/logger"
+	"github.com/Ja7ad/meilisitemap/internal/meilitest"
+	"github.com/Ja7ad/meilisitemap/internal/report"
+	"github.com/Ja7ad/meilisitemap/internal/sitemap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateRetention(t *testing.T) {
+	srv := meilitest.New()
+	defer srv.Close()
+
+	store := t.TempDir()
+	general, sitemaps := newTestConfig(srv.URL)
+	sitemaps["movies"].Retention = &config.RetentionConfig{MaxShrink: 50, GracePeriod: 200 * time.Millisecond}
+
+	runs := []struct {
+		name     string
+		docs     []map[string]any
+		wait     time.Duration
+		added    int
+		removed  int
+		retained int
+		locs     int
+		err      error
+	}{
+		{name: "first run", docs: newTestDocs(10), added: 10, locs: 10},
+		{name: "removed documents", docs: append(newTestDocs(8), testDoc(11)), added: 1, removed: 2, retained: 2, locs: 11},
+		{name: "still in grace period", docs: append(newTestDocs(8), testDoc(11)), retained: 2, locs: 11},
+		{name: "empty fetch", docs: newTestDocs(0), err: ErrShrinkExceeded, locs: 11},
+		{name: "grace period passed", docs: append(newTestDocs(8), testDoc(11)), wait: 200 * time.Millisecond, locs: 9},
+		{name: "added back", docs: newTestDocs(10), added: 2, removed: 1, retained: 1, locs: 11},
+	}
+
+	for _, run := range runs {
+		t.Run(run.name, func(t *testing.T) {
+			time.Sleep(run.wait)
+			srv.AddIndex("movies", run.docs, nil)
+
+			g, err := New(context.Background(), store, general, logger.DefaultLogger, sitemaps, WithOnce())
+			require.NoError(t, err)
+
+			err = g.generate(context.Background(), "movies", sitemaps["movies"])
+			if run.err != nil {
+				assert.ErrorIs(t, err, run.err)
+			} else {
+				require.NoError(t, err)
+
+				rep, err := report.Load(store)
+				require.NoError(t, err)
+				ir := rep.Indexes[0]
+				assert.Equal(t, run.added, ir.Added, "added")
+				assert.Equal(t, run.removed, ir.Removed, "removed")
+				assert.Equal(t, run.retained, ir.Retained, "retained")
+			}
+
+			b, err := os.ReadFile(filepath.Join(store, "sitemaps", "movies.xml"))
+			require.NoError(t, err)
+			assert.Equal(t, run.locs, bytes.Count(b, []byte("<loc>")))
+		})
+	}
+}
+
+func TestCheckShrink(t *testing.T) {
+	prev := &urlState{
+		URLs:    map[string]*sitemap.URL{"a": {}, "b": {}, "c": {}, "d": {}, "e": {}},
+		Removed: map[string]time.Time{"e": time.Now()},
+	}
+
+	tests := []struct {
+		name      string
+		prev      *urlState
+		urls      int
+		retention *config.RetentionConfig
+		wantErr   bool
+	}{
+		{name: "disabled", prev: prev, urls: 0},
+		{name: "zero max shrink", prev: prev, urls: 0, retention: &config.RetentionConfig{}},
+		{name: "first run", prev: &urlState{}, urls: 0, retention: &config.RetentionConfig{MaxShrink: 10}},
+		{name: "grown", prev: prev, urls: 6, retention: &config.RetentionConfig{MaxShrink: 10}},
+		{name: "shrink at max", prev: prev, urls: 3, retention: &config.RetentionConfig{MaxShrink: 25}},
+		{name: "shrink over max", prev: prev, urls: 2, retention: &config.RetentionConfig{MaxShrink: 25}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkShrink(tt.prev, tt.urls, tt.retention)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrShrinkExceeded)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func testDoc(id int) map[string]any {
+	return map[string]any{"id": id, "created_at": "2024-01-02T15:04:05Z"}
+}
+
+func TestLoadState(t *testing.T) {
+	srv := meilitest.New()
+	defer srv.Close()
+	srv.AddIndex("movies", newTestDocs(3), nil)
+
+	store := t.TempDir()
+	general, sitemaps := newTestConfig(srv.URL)
+
+	g, err := New(context.Background(), store, general, logger.DefaultLogger, sitemaps, WithOnce())
+	require.NoError(t, err)
+	require.NoError(t, g.generate(context.Background(), "movies", sitemaps["movies"]))
+
+	// state is kept out of served store.
+	path := filepath.Join(store+_stateSuffix, "movies.json")
+	require.FileExists(t, path)
+	assert.NoDirExists(t, filepath.Join(store, "state"))
+
+	prev, err := g.loadState("movies")
+	require.NoError(t, err)
+	assert.Len(t, prev.URLs, 3)
+
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0o644))
+
+	prev, err = g.loadState("movies")
+	require.NoError(t, err)
+	assert.Empty(t, prev.URLs)
+	assert.Empty(t, prev.Removed)
+
+	general.StatePath = t.TempDir()
+	g, err = New(context.Background(), store, general, logger.DefaultLogger, sitemaps, WithOnce())
+	require.NoError(t, err)
+	require.NoError(t, g.generate(context.Background(), "movies", sitemaps["movies"]))
+	assert.FileExists(t, filepath.Join(general.StatePath, "movies.json"))
+}
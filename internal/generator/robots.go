@@ -0,0 +1,88 @@
+package generator
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// _robotsFile of store, served on root of server.
+const _robotsFile = "robots.txt"
+
+// _defaultRobotsRules allow all user agents when no template is set.
+const _defaultRobotsRules = "User-agent: *\nAllow: /\n"
+
+// writeRobots write robots.txt of store with rules of template and Sitemap line of root index.
+func (s *Sitemap) writeRobots() error {
+	rules := []byte(_defaultRobotsRules)
+	if s.robots.Template != "" {
+		b, err := os.ReadFile(s.robots.Template)
+		if err != nil {
+			return fmt.Errorf("failed to read robots template: %w", err)
+		}
+		rules = b
+	}
+
+	loc, err := url.JoinPath(s.baseLoc(), s.indexFileName())
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(s.storePath, _robotsFile)
+
+	if err := writeFileAtomic(path, robotsTxt(rules, loc)); err != nil {
+		return fmt.Errorf("error writing robots %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// robotsTxt merge rules with Sitemap lines of sitemaps, Sitemap lines of rules are moved to end
+// with sitemaps and duplicates are dropped.
+func robotsTxt(rules []byte, sitemaps ...string) []byte {
+	var (
+		body strings.Builder
+		seen = make(map[string]bool)
+		locs = make([]string, 0, len(sitemaps))
+	)
+
+	add := func(loc string) {
+		if !seen[loc] {
+			seen[loc] = true
+			locs = append(locs, loc)
+		}
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(rules))
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), " \t\r")
+
+		if key, val, ok := strings.Cut(line, ":"); ok && strings.EqualFold(strings.TrimSpace(key), "sitemap") {
+			add(strings.TrimSpace(val))
+			continue
+		}
+
+		body.WriteString(line)
+		body.WriteByte('\n')
+	}
+
+	for _, loc := range sitemaps {
+		add(loc)
+	}
+
+	var buf bytes.Buffer
+	if b := strings.TrimRight(body.String(), "\n"); b != "" {
+		buf.WriteString(b)
+		buf.WriteString("\n\n")
+	}
+
+	for _, loc := range locs {
+		fmt.Fprintf(&buf, "Sitemap: %s\n", loc)
+	}
+
+	return buf.Bytes()
+}
@@ -0,0 +1,73 @@
+package generator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/Ja7ad/meilisitemap/internal/logger"
+	"github.com/Ja7ad/meilisitemap/internal/meilitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRobotsTxt(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+		want  string
+	}{
+		{
+			name:  "default rules",
+			rules: _defaultRobotsRules,
+			want:  "User-agent: *\nAllow: /\n\nSitemap: https://example.com/sitemap.xml\n",
+		},
+		{
+			name:  "no rules",
+			rules: "",
+			want:  "Sitemap: https://example.com/sitemap.xml\n",
+		},
+		{
+			name:  "sitemap lines of rules moved to end",
+			rules: "User-agent: *\nSitemap: https://example.com/blog.xml\nDisallow: /admin\n\n\n",
+			want: "User-agent: *\nDisallow: /admin\n\n" +
+				"Sitemap: https://example.com/blog.xml\nSitemap: https://example.com/sitemap.xml\n",
+		},
+		{
+			name:  "duplicate sitemap dropped",
+			rules: "User-agent: *\r\nDisallow:\r\nsitemap:https://example.com/sitemap.xml\r\n",
+			want:  "User-agent: *\nDisallow:\n\nSitemap: https://example.com/sitemap.xml\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := robotsTxt([]byte(tt.rules), "https://example.com/sitemap.xml")
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestGenerateRobots(t *testing.T) {
+	srv := meilitest.New()
+	defer srv.Close()
+	srv.AddIndex("movies", newTestDocs(3), nil)
+
+	template := filepath.Join(t.TempDir(), "robots.txt")
+	require.NoError(t, os.WriteFile(template, []byte("User-agent: *\nDisallow: /search\n"), 0o644))
+
+	store := t.TempDir()
+	general, sitemaps := newTestConfig(srv.URL)
+	general.FileName = "index"
+	general.Robots = &config.RobotsConfig{Enabled: true, Template: template}
+
+	g, err := New(context.Background(), store, general, logger.DefaultLogger, sitemaps, WithOnce())
+	require.NoError(t, err)
+	require.NoError(t, g.Start())
+
+	b, err := os.ReadFile(filepath.Join(store, "robots.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "User-agent: *\nDisallow: /search\n\nSitemap: https://example.com/index.xml\n", string(b))
+}
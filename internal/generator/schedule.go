@@ -0,0 +1,40 @@
+package generator
+
+import (
+	"time"
+
+	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/Ja7ad/meilisitemap/internal/sched"
+)
+
+// liveSchedule returns schedule of live update by interval mode, cron has priority over every and
+// interval in seconds.
+func liveSchedule(sm *config.SitemapConfig) (sched.Schedule, error) {
+	live := sm.LiveUpdate
+
+	switch {
+	case live.Cron != "":
+		loc := time.Local
+		if live.Timezone != "" {
+			var err error
+			if loc, err = time.LoadLocation(live.Timezone); err != nil {
+				return nil, err
+			}
+		}
+		return sched.Cron(live.Cron, loc)
+	case live.Every == config.EveryChangeFreq:
+		var freq config.ChangeFreq
+		if sm.FieldMap != nil {
+			freq = sm.FieldMap.ChangeFreq
+		}
+		return sched.Every(freq.Interval()), nil
+	case live.Every != "":
+		d, err := time.ParseDuration(live.Every)
+		if err != nil {
+			return nil, err
+		}
+		return sched.Every(d), nil
+	default:
+		return sched.Every(time.Duration(live.Interval) * time.Second), nil
+	}
+}
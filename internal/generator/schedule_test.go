@@ -0,0 +1,69 @@
+package generator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLiveSchedule(t *testing.T) {
+	from := time.Date(2024, 1, 2, 10, 30, 0, 0, time.UTC)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		live     *config.LiveConfig
+		fieldMap *config.FieldMapConfig
+		want     time.Time
+		wantErr  bool
+	}{
+		{
+			name: "interval seconds",
+			live: &config.LiveConfig{Interval: 60},
+			want: from.Add(time.Minute),
+		},
+		{
+			name: "every duration",
+			live: &config.LiveConfig{Every: "90m"},
+			want: from.Add(90 * time.Minute),
+		},
+		{
+			name:     "every changefreq",
+			live:     &config.LiveConfig{Every: config.EveryChangeFreq},
+			fieldMap: &config.FieldMapConfig{ChangeFreq: config.Always},
+			want:     from.Add(5 * time.Minute),
+		},
+		{
+			name: "changefreq without field map",
+			live: &config.LiveConfig{Every: config.EveryChangeFreq},
+			want: from.Add(time.Hour),
+		},
+		{
+			name: "cron in timezone",
+			live: &config.LiveConfig{Cron: "0 3 * * *", Timezone: "Europe/Berlin"},
+			want: time.Date(2024, 1, 3, 3, 0, 0, 0, berlin),
+		},
+		{
+			name:    "invalid cron",
+			live:    &config.LiveConfig{Cron: "* *"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := liveSchedule(&config.SitemapConfig{LiveUpdate: tt.live, FieldMap: tt.fieldMap})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(schedule.Next(from)), "next run %s", schedule.Next(from))
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/Ja7ad/meilisitemap/internal/tracing"
+	"github.com/meilisearch/meilisearch-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	_defaultSnapshotTimeout = 5 * time.Minute
+	_taskPollInterval       = 250 * time.Millisecond
+)
+
+// snapshot wait for tasks of source index pending at start of run when enabled, and returns
+// uid of last succeeded task of source index. Tasks enqueued after start of run are not waited,
+// so a continuous ingestion can't block generation.
+func (s *Sitemap) snapshot(ctx context.Context, index string, sm *config.SitemapConfig) (taskUID *int64, err error) {
+	if sm.Snapshot == nil {
+		return nil, nil
+	}
+
+	src := sm.SourceIndex(index)
+
+	ctx, span := tracing.Tracer().Start(ctx, "generator.snapshot", trace.WithAttributes(attribute.String("index", src)))
+	defer func() {
+		if taskUID != nil {
+			span.SetAttributes(attribute.Int64("task_uid", *taskUID))
+		}
+		tracing.End(span, err)
+	}()
+
+	if sm.Snapshot.WaitTasks {
+		if err := s.waitTasks(ctx, src, sm.Snapshot.Timeout); err != nil {
+			return nil, err
+		}
+	}
+
+	last, err := s.lastTask(ctx, &meilisearch.TasksQuery{
+		IndexUIDS: []string{src},
+		Statuses:  []meilisearch.TaskStatus{meilisearch.TaskStatusSucceeded},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last task of index %s: %w", src, err)
+	}
+
+	if last == nil {
+		return nil, nil
+	}
+
+	return &last.UID, nil
+}
+
+// waitTasks wait until latest enqueued or processing task of index is finished, tasks of
+// an index are processed in order so every pending task before it is finished too.
+func (s *Sitemap) waitTasks(ctx context.Context, index string, timeout time.Duration) error {
+	pending, err := s.lastTask(ctx, &meilisearch.TasksQuery{
+		IndexUIDS: []string{index},
+		Statuses:  []meilisearch.TaskStatus{meilisearch.TaskStatusEnqueued, meilisearch.TaskStatusProcessing},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get pending tasks of index %s: %w", index, err)
+	}
+
+	if pending == nil {
+		return nil
+	}
+
+	s.logger.Info("waiting for pending tasks of index", "index", index, "task_uid", pending.UID)
+
+	ctx, cancel := context.WithTimeout(ctx, orDefaultDuration(timeout, _defaultSnapshotTimeout))
+	defer cancel()
+
+	task, err := s.meili.WaitForTaskWithContext(ctx, pending.UID, _taskPollInterval)
+	if err != nil {
+		return fmt.Errorf("failed to wait for task %d of index %s: %w", pending.UID, index, err)
+	}
+
+	if task.Status != meilisearch.TaskStatusSucceeded {
+		s.logger.Warn("pending task of index not succeeded", "index", index, "task_uid", task.UID, "status", task.Status)
+	}
+
+	return nil
+}
+
+// lastTask returns latest task matched by query, nil if there is no such task.
+func (s *Sitemap) lastTask(ctx context.Context, query *meilisearch.TasksQuery) (*meilisearch.Task, error) {
+	var res *meilisearch.TaskResult
+
+	query.Limit = 1
+
+	err := s.retry.Do(ctx, func(ctx context.Context) (err error) {
+		res, err = s.meili.GetTasksWithContext(ctx, query)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// tasks are listed newest first.
+	if len(res.Results) == 0 {
+		return nil, nil
+	}
+
+	return &res.Results[0], nil
+}
+
+func orDefaultDuration(v, def time.Duration) time.Duration {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,99 @@
+package generator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/Ja7ad/meilisitemap/internal/logger"
+	"github.com/Ja7ad/meilisitemap/internal/meilitest"
+	"github.com/Ja7ad/meilisitemap/internal/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateSnapshot(t *testing.T) {
+	tests := []struct {
+		name     string
+		index    string
+		snapshot *config.SnapshotConfig
+		pending  bool
+		finish   bool
+		source   string
+		taskUID  *int64
+		wantErr  bool
+	}{
+		{name: "disabled", index: "movies"},
+		{name: "last task", index: "movies", snapshot: &config.SnapshotConfig{}, taskUID: ptr(int64(0))},
+		{
+			name:     "wait pending task",
+			index:    "movies",
+			snapshot: &config.SnapshotConfig{WaitTasks: true},
+			pending:  true,
+			finish:   true,
+			taskUID:  ptr(int64(1)),
+		},
+		{
+			name:     "swap index",
+			index:    "movies_live",
+			snapshot: &config.SnapshotConfig{WaitTasks: true, Index: "movies_live"},
+			source:   "movies_live",
+			taskUID:  ptr(int64(0)),
+		},
+		{
+			name:     "timeout",
+			index:    "movies",
+			snapshot: &config.SnapshotConfig{WaitTasks: true, Timeout: 100 * time.Millisecond},
+			pending:  true,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := meilitest.New()
+			defer srv.Close()
+			srv.AddIndex(tt.index, newTestDocs(3), nil)
+			srv.AddTask(tt.index, "succeeded")
+
+			store := t.TempDir()
+			general, sitemaps := newTestConfig(srv.URL)
+			sitemaps["movies"].Snapshot = tt.snapshot
+
+			g, err := New(context.Background(), store, general, logger.DefaultLogger, sitemaps, WithOnce())
+			require.NoError(t, err)
+
+			if tt.pending {
+				uid := srv.AddTask(tt.index, "processing")
+				if tt.finish {
+					time.AfterFunc(300*time.Millisecond, func() { srv.SetTaskStatus(uid, "succeeded") })
+				}
+			}
+
+			err = g.generate(context.Background(), "movies", sitemaps["movies"])
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			rep, err := report.Load(store)
+			require.NoError(t, err)
+			require.Len(t, rep.Indexes, 1)
+
+			ir := rep.Indexes[0]
+			assert.Equal(t, tt.source, ir.Source)
+			assert.Equal(t, tt.taskUID, ir.TaskUID)
+			assert.Equal(t, 3, ir.URLs)
+
+			if tt.finish {
+				assert.GreaterOrEqual(t, ir.DurationMS, int64(250), "documents must be fetched after pending task")
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
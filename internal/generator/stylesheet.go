@@ -0,0 +1,60 @@
+package generator
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/Ja7ad/meilisitemap/stylesheets"
+)
+
+// _stylesheetDir of store keeps stylesheets linked by sitemaps.
+const _stylesheetDir = "stylesheets"
+
+// roles of stylesheets, custom stylesheets are written by name of their role.
+const (
+	_sitemapStylesheet = "sitemap"
+	_indexStylesheet   = "index"
+	_feedStylesheet    = "feed"
+)
+
+// stylesheetLink write bundled or custom stylesheet file to store and returns its link, so it's
+// served under same origin of sitemaps. Url stylesheets are linked as is. Custom stylesheets are
+// named by role, so files of different roles with same name don't overwrite each other.
+func (s *Sitemap) stylesheetLink(st config.Stylesheet, role string) (string, error) {
+	if st == "" || st.IsURL() {
+		return string(st), nil
+	}
+
+	var (
+		name string
+		data []byte
+		err  error
+	)
+
+	if st.Bundled() {
+		name = string(st) + ".xsl"
+		data, err = stylesheets.FS.ReadFile(name)
+	} else {
+		name = role + ".xsl"
+		data, err = os.ReadFile(string(st))
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read stylesheet %s: %w", st, err)
+	}
+
+	if s.dryRun == nil {
+		dir := filepath.Join(s.storePath, _stylesheetDir)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return "", err
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			return "", fmt.Errorf("error writing stylesheet %s: %w", name, err)
+		}
+	}
+
+	return url.JoinPath(s.baseLoc(), _stylesheetDir, name)
+}
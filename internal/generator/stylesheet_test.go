@@ -0,0 +1,127 @@
+package generator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/Ja7ad/meilisitemap/internal/logger"
+	"github.com/Ja7ad/meilisitemap/internal/meilitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateStylesheet(t *testing.T) {
+	custom := filepath.Join(t.TempDir(), "custom.xsl")
+	require.NoError(t, os.WriteFile(custom, []byte("<xsl:stylesheet/>"), 0o644))
+
+	tests := []struct {
+		name       string
+		stylesheet config.Stylesheet
+		index      config.Stylesheet
+		href       string
+		indexHref  string
+		file       string
+	}{
+		{
+			name:       "bundled",
+			stylesheet: config.Style1,
+			index:      config.Style1,
+			href:       "https://example.com/stylesheets/style1.xsl",
+			indexHref:  "https://example.com/stylesheets/style1.xsl",
+			file:       "style1.xsl",
+		},
+		{
+			name:       "index stylesheet",
+			stylesheet: config.Style1,
+			index:      config.Style2,
+			href:       "https://example.com/stylesheets/style1.xsl",
+			indexHref:  "https://example.com/stylesheets/style2.xsl",
+			file:       "style2.xsl",
+		},
+		{
+			name:       "custom path",
+			stylesheet: config.Stylesheet(custom),
+			href:       "https://example.com/stylesheets/sitemap.xsl",
+			file:       "sitemap.xsl",
+		},
+		{
+			name:       "url",
+			stylesheet: "https://cdn.example.com/sitemap.xsl",
+			href:       "https://cdn.example.com/sitemap.xsl",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := meilitest.New()
+			defer srv.Close()
+			srv.AddIndex("movies", newTestDocs(3), nil)
+
+			store := t.TempDir()
+			general, sitemaps := newTestConfig(srv.URL)
+			general.Stylesheet = tt.stylesheet
+			general.IndexStylesheet = tt.index
+
+			g, err := New(context.Background(), store, general, logger.DefaultLogger, sitemaps, WithOnce())
+			require.NoError(t, err)
+			require.NoError(t, g.Start())
+
+			b, err := os.ReadFile(filepath.Join(store, "sitemaps", "movies.xml"))
+			require.NoError(t, err)
+			assert.Contains(t, string(b), `<?xml-stylesheet type="text/xsl" href="`+tt.href+`"?>`)
+
+			b, err = os.ReadFile(filepath.Join(store, "sitemap.xml"))
+			require.NoError(t, err)
+			if tt.indexHref != "" {
+				assert.Contains(t, string(b), `<?xml-stylesheet type="text/xsl" href="`+tt.indexHref+`"?>`)
+			} else {
+				assert.NotContains(t, string(b), "xml-stylesheet")
+			}
+			assert.Contains(t, string(b), "<sitemapindex")
+
+			if tt.file != "" {
+				assert.FileExists(t, filepath.Join(store, _stylesheetDir, tt.file))
+			} else {
+				assert.NoDirExists(t, filepath.Join(store, _stylesheetDir))
+			}
+		})
+	}
+}
+
+func TestGenerateStylesheetSameName(t *testing.T) {
+	srv := meilitest.New()
+	defer srv.Close()
+	srv.AddIndex("movies", newTestDocs(3), nil)
+
+	dir := t.TempDir()
+	paths := make(map[string]string)
+	for _, role := range []string{_sitemapStylesheet, _indexStylesheet, _feedStylesheet} {
+		path := filepath.Join(dir, role, "style.xsl")
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte("<xsl:stylesheet id=\""+role+"\"/>"), 0o644))
+		paths[role] = path
+	}
+
+	store := t.TempDir()
+	general, sitemaps := newTestConfig(srv.URL)
+	general.Stylesheet = config.Stylesheet(paths[_sitemapStylesheet])
+	general.IndexStylesheet = config.Stylesheet(paths[_indexStylesheet])
+	general.FeedStylesheet = config.Stylesheet(paths[_feedStylesheet])
+
+	g, err := New(context.Background(), store, general, logger.DefaultLogger, sitemaps, WithOnce())
+	require.NoError(t, err)
+	require.NoError(t, g.Start())
+
+	for role := range paths {
+		b, err := os.ReadFile(filepath.Join(store, _stylesheetDir, role+".xsl"))
+		require.NoError(t, err)
+		assert.Equal(t, "<xsl:stylesheet id=\""+role+"\"/>", string(b))
+	}
+
+	b, err := os.ReadFile(filepath.Join(store, "sitemap.xml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `href="https://example.com/stylesheets/index.xsl"`)
+}
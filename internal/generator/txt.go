@@ -0,0 +1,183 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/Ja7ad/meilisitemap/internal/metrics"
+	"github.com/Ja7ad/meilisitemap/internal/sitemap"
+	"github.com/Ja7ad/meilisitemap/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// limits of a text sitemap file by sitemap protocol, bytes are uncompressed size.
+const (
+	_txtMaxURLs  = 50_000
+	_txtMaxBytes = 50 << 20
+)
+
+// txtChunks split locs of urls to text files of one url per line within protocol limits.
+func txtChunks(urls []*sitemap.URL, maxURLs, maxBytes int) [][]byte {
+	var (
+		chunks [][]byte
+		buf    bytes.Buffer
+		n      int
+	)
+
+	for _, u := range urls {
+		if n == maxURLs || (n > 0 && buf.Len()+len(u.Loc)+1 > maxBytes) {
+			chunks = append(chunks, bytes.Clone(buf.Bytes()))
+			buf.Reset()
+			n = 0
+		}
+
+		buf.WriteString(u.Loc)
+		buf.WriteByte('\n')
+		n++
+	}
+
+	if n > 0 || len(chunks) == 0 {
+		chunks = append(chunks, buf.Bytes())
+	}
+
+	return chunks
+}
+
+// writeTxt write urls of set as text sitemap of index, urls beyond protocol limits are split to
+// numbered files like movies.2.txt and stale parts of previous runs are removed. File names are
+// returned for text index.
+func (s *Sitemap) writeTxt(ctx context.Context, idx string, sm *config.SitemapConfig, set *sitemap.URLSet) (files []string, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "generator.writeTxt", trace.WithAttributes(attribute.String("index", idx)))
+	defer func() {
+		tracing.End(span, err)
+	}()
+
+	chunks := txtChunks(set.URLs, _txtMaxURLs, _txtMaxBytes)
+
+	for i, b := range chunks {
+		fileName := s.txtFileName(idx, sm, i)
+
+		if sm.Compress {
+			if b, err = sitemap.Compress(ctx, b); err != nil {
+				return nil, err
+			}
+		}
+
+		file := filepath.Join(s.indexsitemapPath, fileName)
+		path := filepath.Join(s.storePath, file)
+
+		if err := writeFileAtomic(path, b); err != nil {
+			return nil, fmt.Errorf("error writing text sitemap %s: %w", path, err)
+		}
+
+		metrics.SetFileSize(file, len(b))
+		files = append(files, fileName)
+	}
+
+	return files, s.removeStaleTxt(idx, sm, len(chunks))
+}
+
+// removeStaleTxt remove numbered parts of text sitemap of index from part n, and every part
+// of other extension left by toggling compression.
+func (s *Sitemap) removeStaleTxt(idx string, sm *config.SitemapConfig, n int) error {
+	dir := filepath.Join(s.storePath, s.indexsitemapPath)
+	base := s.baseFileName(idx, sm)
+	ext := s.txtExt(sm)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		name, ok := strings.CutPrefix(e.Name(), base)
+		if !ok {
+			continue
+		}
+
+		var partExt string
+		for _, x := range []string{".txt.gz", ".txt"} {
+			if name, ok = strings.CutSuffix(name, x); ok {
+				partExt = x
+				break
+			}
+		}
+		if partExt == "" {
+			continue
+		}
+
+		stale := false
+		switch part, numbered := strings.CutPrefix(name, "."); {
+		case name == "":
+			stale = partExt != ext
+		case numbered:
+			i, err := strconv.Atoi(part)
+			stale = err == nil && (i > n || partExt != ext)
+		}
+
+		if stale {
+			if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// txtFileName of part i of text sitemap, first part is named like sitemap of index.
+func (s *Sitemap) txtFileName(idx string, sm *config.SitemapConfig, i int) string {
+	fileName := s.baseFileName(idx, sm)
+	if i > 0 {
+		fileName += "." + strconv.Itoa(i+1)
+	}
+	return fileName + s.txtExt(sm)
+}
+
+func (s *Sitemap) txtExt(sm *config.SitemapConfig) string {
+	if sm.Compress {
+		return ".txt.gz"
+	}
+	return ".txt"
+}
+
+// createTxtIndex write text index of txt sitemaps of every index, one link per line.
+func (s *Sitemap) createTxtIndex() error {
+	names := make([]string, 0, len(s.txtFiles))
+	for idx := range s.txtFiles {
+		names = append(names, idx)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, idx := range names {
+		for _, fn := range s.txtFiles[idx] {
+			loc, err := url.JoinPath(s.baseLoc(), s.indexsitemapPath, fn)
+			if err != nil {
+				return err
+			}
+			buf.WriteString(loc)
+			buf.WriteByte('\n')
+		}
+	}
+
+	fileName := strings.TrimSuffix(s.indexFileName(), ".xml") + ".txt"
+	path := filepath.Join(s.storePath, fileName)
+
+	if err := writeFileAtomic(path, buf.Bytes()); err != nil {
+		return fmt.Errorf("error writing text index %s: %w", path, err)
+	}
+
+	metrics.SetFileSize(fileName, buf.Len())
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/Ja7ad/meilisitemap/internal/logger"
+	"github.com/Ja7ad/meilisitemap/internal/meilitest"
+	"github.com/Ja7ad/meilisitemap/internal/sitemap"
+	"github.com/klauspost/compress/gzip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxtChunks(t *testing.T) {
+	urls := []*sitemap.URL{
+		{Loc: "https://example.com/1"},
+		{Loc: "https://example.com/2"},
+		{Loc: "https://example.com/3"},
+	}
+
+	tests := []struct {
+		name     string
+		urls     []*sitemap.URL
+		maxURLs  int
+		maxBytes int
+		want     []string
+	}{
+		{
+			name:     "single file",
+			urls:     urls,
+			maxURLs:  10,
+			maxBytes: 1000,
+			want:     []string{"https://example.com/1\nhttps://example.com/2\nhttps://example.com/3\n"},
+		},
+		{
+			name:     "urls limit",
+			urls:     urls,
+			maxURLs:  2,
+			maxBytes: 1000,
+			want:     []string{"https://example.com/1\nhttps://example.com/2\n", "https://example.com/3\n"},
+		},
+		{
+			name:     "bytes limit",
+			urls:     urls,
+			maxURLs:  10,
+			maxBytes: 30,
+			want:     []string{"https://example.com/1\n", "https://example.com/2\n", "https://example.com/3\n"},
+		},
+		{name: "empty", maxURLs: 10, maxBytes: 1000, want: []string{""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]string, 0)
+			for _, b := range txtChunks(tt.urls, tt.maxURLs, tt.maxBytes) {
+				got = append(got, string(b))
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGenerateTxt(t *testing.T) {
+	srv := meilitest.New()
+	defer srv.Close()
+	srv.AddIndex("movies", newTestDocs(3), nil)
+
+	store := t.TempDir()
+	general, sitemaps := newTestConfig(srv.URL)
+	general.TxtIndex = true
+	sitemaps["movies"].Txt = true
+	sitemaps["movies"].Compress = true
+
+	// stale part of previous run with more urls.
+	require.NoError(t, os.MkdirAll(filepath.Join(store, "sitemaps"), 0o755))
+	stale := filepath.Join(store, "sitemaps", "movies.2.txt.gz")
+	require.NoError(t, os.WriteFile(stale, nil, 0o644))
+
+	g, err := New(context.Background(), store, general, logger.DefaultLogger, sitemaps, WithOnce())
+	require.NoError(t, err)
+	require.NoError(t, g.Start())
+
+	f, err := os.Open(filepath.Join(store, "sitemaps", "movies.txt.gz"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	r, err := gzip.NewReader(f)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(r)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/movies/1\nhttps://example.com/movies/2\nhttps://example.com/movies/3\n", buf.String())
+	assert.NoFileExists(t, stale)
+
+	b, err := os.ReadFile(filepath.Join(store, "sitemap.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/sitemaps/movies.txt.gz\n", string(b))
+}
+
+func TestRemoveStaleTxt(t *testing.T) {
+	files := []string{
+		"movies.txt", "movies.2.txt", "movies.3.txt",
+		"movies.txt.gz", "movies.2.txt.gz",
+		"movies.xml", "series.2.txt",
+	}
+
+	tests := []struct {
+		name     string
+		compress bool
+		n        int
+		want     []string
+	}{
+		{
+			name: "fewer parts",
+			n:    2,
+			want: []string{"movies.2.txt", "movies.txt", "movies.xml", "series.2.txt"},
+		},
+		{
+			name:     "compression enabled",
+			compress: true,
+			n:        1,
+			want:     []string{"movies.txt.gz", "movies.xml", "series.2.txt"},
+		},
+		{
+			name: "compression disabled",
+			n:    3,
+			want: []string{"movies.2.txt", "movies.3.txt", "movies.txt", "movies.xml", "series.2.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := t.TempDir()
+			dir := filepath.Join(store, "sitemaps")
+			require.NoError(t, os.MkdirAll(dir, 0o755))
+			for _, name := range files {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o644))
+			}
+
+			s := &Sitemap{storePath: store, indexsitemapPath: "sitemaps"}
+			require.NoError(t, s.removeStaleTxt("movies", &config.SitemapConfig{Compress: tt.compress}, tt.n))
+
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+
+			got := make([]string, 0, len(entries))
+			for _, e := range entries {
+				got = append(got, e.Name())
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package generator
+
+import (
+	"time"
+
+	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/meilisearch/meilisearch-go"
+)
+
+const (
+	_defaultPollInterval = 5 * time.Second
+	_defaultDebounce     = 10 * time.Second
+	_defaultMaxDelay     = time.Minute
+)
+
+// documentTasks are task types which change documents of index.
+var documentTasks = []meilisearch.TaskType{
+	meilisearch.TaskTypeDocumentAdditionOrUpdate,
+	meilisearch.TaskTypeDocumentDeletion,
+}
+
+// watchTasks poll tasks API for succeeded document tasks of index and call regenerate when index
+// is quiet for debounce, or max delay passed since first task not regenerated yet, so a continuous
+// ingestion still regenerate sitemap. Tasks succeeded before watching are covered by first run,
+// if they can't be listed then latest task found by first successful poll regenerate sitemap once.
+func (s *Sitemap) watchTasks(idx string, sm *config.SitemapConfig, regenerate func()) {
+	live := sm.LiveUpdate
+	src := sm.SourceIndex(idx)
+
+	pollInterval := orDefaultDuration(live.PollInterval, _defaultPollInterval)
+	debounce := orDefaultDuration(live.Debounce, _defaultDebounce)
+	maxDelay := orDefaultDuration(live.MaxDelay, _defaultMaxDelay)
+
+	query := func() (*meilisearch.Task, error) {
+		return s.lastTask(s.ctx, &meilisearch.TasksQuery{
+			IndexUIDS: []string{src},
+			Statuses:  []meilisearch.TaskStatus{meilisearch.TaskStatusSucceeded},
+			Types:     documentTasks,
+		})
+	}
+
+	lastUID := int64(-1)
+	if task, err := query(); err != nil {
+		s.logger.Warn("failed to get tasks of index", "index", src, "err", err)
+	} else if task != nil {
+		lastUID = task.UID
+	}
+
+	s.logger.Info("watching tasks of index", "index", src, "poll_interval", pollInterval, "debounce", debounce)
+
+	poll := time.NewTicker(pollInterval)
+	defer poll.Stop()
+
+	var (
+		timer   *time.Timer
+		fire    <-chan time.Time
+		pending time.Time // first task not regenerated yet
+	)
+
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-poll.C:
+			task, err := query()
+			if err != nil {
+				if s.ctx.Err() != nil {
+					return
+				}
+				s.logger.Warn("failed to get tasks of index", "index", src, "err", err)
+				continue
+			}
+
+			if task == nil || task.UID <= lastUID {
+				continue
+			}
+			lastUID = task.UID
+
+			now := time.Now()
+			if pending.IsZero() {
+				pending = now
+			}
+
+			delay := max(min(debounce, maxDelay-now.Sub(pending)), 0)
+
+			if timer == nil {
+				timer = time.NewTimer(delay)
+			} else {
+				timer.Reset(delay)
+			}
+			fire = timer.C
+
+			s.logger.Debug("document task of index succeeded", "index", src, "task_uid", task.UID, "delay", delay)
+		case <-fire:
+			fire = nil
+			pending = time.Time{}
+
+			s.logger.Info("regenerating sitemap by tasks of index", "index", src, "task_uid", lastUID)
+			regenerate()
+		}
+	}
+}
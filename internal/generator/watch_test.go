@@ -0,0 +1,125 @@
+package generator
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/Ja7ad/meilisitemap/internal/logger"
+	"github.com/Ja7ad/meilisitemap/internal/meilitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchTasks(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxDelay time.Duration
+		failures int // failed requests of tasks before watching
+		feed     func(srv *meilitest.Server)
+		want     int32
+		atLeast  bool
+	}{
+		{name: "no tasks", feed: func(*meilitest.Server) {}, want: 0},
+		{
+			name: "debounced burst",
+			feed: func(srv *meilitest.Server) {
+				for range 3 {
+					srv.AddTask("movies", "succeeded")
+					time.Sleep(20 * time.Millisecond)
+				}
+			},
+			want: 1,
+		},
+		{
+			name: "not relevant tasks",
+			feed: func(srv *meilitest.Server) {
+				srv.AddTaskOf("movies", "succeeded", "settingsUpdate")
+				srv.AddTask("movies", "processing")
+				srv.AddTask("movies", "failed")
+				srv.AddTask("series", "succeeded")
+			},
+			want: 0,
+		},
+		{
+			name:     "tasks failed before watching",
+			failures: 1,
+			feed:     func(*meilitest.Server) {},
+			want:     1,
+		},
+		{
+			name:     "tasks failed before watching with burst",
+			failures: 1,
+			feed: func(srv *meilitest.Server) {
+				for range 3 {
+					srv.AddTask("movies", "succeeded")
+					time.Sleep(20 * time.Millisecond)
+				}
+			},
+			want: 1,
+		},
+		{
+			name:     "continuous tasks",
+			maxDelay: 200 * time.Millisecond,
+			feed: func(srv *meilitest.Server) {
+				for range 20 {
+					srv.AddTask("movies", "succeeded")
+					time.Sleep(20 * time.Millisecond)
+				}
+			},
+			want:    2,
+			atLeast: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := meilitest.New()
+			defer srv.Close()
+			srv.AddIndex("movies", newTestDocs(3), nil)
+			// succeeded before watching, covered by first run.
+			srv.AddTask("movies", "succeeded")
+
+			general, sitemaps := newTestConfig(srv.URL)
+			once := 1
+			general.MeiliSearch.Retry = &config.RetryConfig{MaxAttempts: &once}
+			sitemaps["movies"].LiveUpdate = &config.LiveConfig{
+				Enabled:      true,
+				Mode:         config.LiveTasks,
+				PollInterval: 10 * time.Millisecond,
+				Debounce:     100 * time.Millisecond,
+				MaxDelay:     tt.maxDelay,
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			g, err := New(ctx, t.TempDir(), general, logger.DefaultLogger, sitemaps)
+			require.NoError(t, err)
+
+			srv.FailNext("GET /tasks", tt.failures)
+
+			var runs atomic.Int32
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				g.watchTasks("movies", sitemaps["movies"], func() { runs.Add(1) })
+			}()
+
+			time.Sleep(30 * time.Millisecond)
+			tt.feed(srv)
+			time.Sleep(250 * time.Millisecond)
+
+			cancel()
+			<-done
+
+			if tt.atLeast {
+				assert.GreaterOrEqual(t, runs.Load(), tt.want)
+			} else {
+				assert.Equal(t, tt.want, runs.Load())
+			}
+		})
+	}
+}
@@ -0,0 +1,578 @@
+// Package meilitest provides an in-memory fake of Meilisearch HTTP API for tests.
+package meilitest
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// _maxTotalHits is default pagination.maxTotalHits of index.
+const _maxTotalHits = 1000
+
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	indexes  map[string]*Index
+	healthy  bool
+	hits     map[string]int
+	failures map[string]int
+	latency  time.Duration
+	inFlight int
+	maxIn    int
+	tasks    []*Task
+}
+
+type Index struct {
+	PrimaryKey string
+	Documents  []map[string]any
+	Settings   map[string]any
+}
+
+type Task struct {
+	UID      int64  `json:"uid"`
+	IndexUID string `json:"indexUid"`
+	Status   string `json:"status"`
+	Type     string `json:"type"`
+}
+
+func New() *Server {
+	s := &Server{
+		indexes:  make(map[string]*Index),
+		healthy:  true,
+		hits:     make(map[string]int),
+		failures: make(map[string]int),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", s.health)
+	mux.HandleFunc("GET /indexes/{uid}", s.index)
+	mux.HandleFunc("GET /indexes/{uid}/settings", s.settings)
+	mux.HandleFunc("GET /indexes/{uid}/stats", s.stats)
+	mux.HandleFunc("GET /indexes/{uid}/documents", s.documents)
+	mux.HandleFunc("POST /indexes/{uid}/documents/fetch", s.documents)
+	mux.HandleFunc("POST /indexes/{uid}/search", s.search)
+	mux.HandleFunc("GET /tasks", s.listTasks)
+	mux.HandleFunc("GET /tasks/{taskUid}", s.task)
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := mux.Handler(r)
+
+		s.mu.Lock()
+		s.inFlight++
+		s.maxIn = max(s.maxIn, s.inFlight)
+		latency := s.latency
+		s.mu.Unlock()
+
+		defer func() {
+			s.mu.Lock()
+			s.inFlight--
+			s.mu.Unlock()
+		}()
+
+		time.Sleep(latency)
+
+		s.mu.Lock()
+		if s.failures[pattern] > 0 {
+			s.failures[pattern]--
+			s.hits[pattern]++
+			s.mu.Unlock()
+			writeError(w, http.StatusServiceUnavailable, "unavailable", "meilisearch is unavailable")
+			return
+		}
+		s.mu.Unlock()
+
+		mux.ServeHTTP(w, r)
+	}))
+
+	return s
+}
+
+// AddIndex add or replace index with documents, settings nil means default settings.
+func (s *Server) AddIndex(uid string, docs []map[string]any, settings map[string]any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if settings == nil {
+		settings = map[string]any{
+			"displayedAttributes":  []string{"*"},
+			"filterableAttributes": []string{},
+			"sortableAttributes":   []string{},
+		}
+	}
+
+	s.indexes[uid] = &Index{PrimaryKey: "id", Documents: docs, Settings: settings}
+}
+
+func (s *Server) SetHealthy(healthy bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.healthy = healthy
+}
+
+// FailNext make next n requests of endpoint pattern fail with 503 status.
+func (s *Server) FailNext(pattern string, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[pattern] = n
+}
+
+// SetLatency delay every response by d.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// MaxInFlight returns max number of concurrent requests served.
+func (s *Server) MaxInFlight() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.maxIn
+}
+
+// Hits returns number of requests received by endpoint pattern, for example "GET /indexes/{uid}/documents".
+func (s *Server) Hits(pattern string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits[pattern]
+}
+
+// AddTask enqueue a document addition task of index with status and returns its uid.
+func (s *Server) AddTask(index, status string) int64 {
+	return s.AddTaskOf(index, status, "documentAdditionOrUpdate")
+}
+
+// AddTaskOf enqueue a task of type for index with status and returns its uid.
+func (s *Server) AddTaskOf(index, status, typ string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := &Task{UID: int64(len(s.tasks)), IndexUID: index, Status: status, Type: typ}
+	s.tasks = append(s.tasks, t)
+	return t.UID
+}
+
+// SetTaskStatus change status of task, for example finish a processing task.
+func (s *Server) SetTaskStatus(uid int64, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tasks[uid].Status = status
+}
+
+func (s *Server) hit(r *http.Request) {
+	s.hits[r.Pattern]++
+}
+
+func (s *Server) health(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hit(r)
+
+	if !s.healthy {
+		writeError(w, http.StatusServiceUnavailable, "unavailable", "meilisearch is unavailable")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "available"})
+}
+
+func (s *Server) index(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hit(r)
+
+	uid := r.PathValue("uid")
+	idx, ok := s.indexes[uid]
+	if !ok {
+		indexNotFound(w, uid)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"uid":        uid,
+		"primaryKey": idx.PrimaryKey,
+		"createdAt":  "2024-01-01T00:00:00Z",
+		"updatedAt":  "2024-01-01T00:00:00Z",
+	})
+}
+
+func (s *Server) settings(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hit(r)
+
+	uid := r.PathValue("uid")
+	idx, ok := s.indexes[uid]
+	if !ok {
+		indexNotFound(w, uid)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, idx.Settings)
+}
+
+func (s *Server) stats(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hit(r)
+
+	uid := r.PathValue("uid")
+	idx, ok := s.indexes[uid]
+	if !ok {
+		indexNotFound(w, uid)
+		return
+	}
+
+	distribution := make(map[string]int64)
+	for _, doc := range idx.Documents {
+		for k := range doc {
+			distribution[k]++
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"numberOfDocuments": len(idx.Documents),
+		"isIndexing":        false,
+		"fieldDistribution": distribution,
+	})
+}
+
+func (s *Server) documents(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hit(r)
+
+	uid := r.PathValue("uid")
+	idx, ok := s.indexes[uid]
+	if !ok {
+		indexNotFound(w, uid)
+		return
+	}
+
+	query := struct {
+		Offset int64    `json:"offset"`
+		Limit  int64    `json:"limit"`
+		Fields []string `json:"fields"`
+		Filter any      `json:"filter"`
+	}{Limit: 20}
+
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+			writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+			return
+		}
+	} else {
+		q := r.URL.Query()
+		if v := q.Get("limit"); v != "" {
+			query.Limit, _ = strconv.ParseInt(v, 10, 64)
+		}
+		if v := q.Get("offset"); v != "" {
+			query.Offset, _ = strconv.ParseInt(v, 10, 64)
+		}
+		if v := q.Get("fields"); v != "" {
+			query.Fields = strings.Split(v, ",")
+		}
+	}
+
+	filter, _ := query.Filter.(string)
+	if filter != "" && !filterable(idx, filter) {
+		writeError(w, http.StatusBadRequest, "invalid_document_filter", "attribute is not filterable")
+		return
+	}
+
+	conds, err := parseFilter(filter)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_document_filter", err.Error())
+		return
+	}
+
+	docs := make([]map[string]any, 0, len(idx.Documents))
+	for _, doc := range idx.Documents {
+		if matchAll(doc, conds) {
+			docs = append(docs, doc)
+		}
+	}
+
+	total := int64(len(docs))
+	results := make([]map[string]any, 0)
+
+	for i := query.Offset; i < total && i < query.Offset+query.Limit; i++ {
+		results = append(results, pick(docs[i], query.Fields))
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"results": results,
+		"offset":  query.Offset,
+		"limit":   query.Limit,
+		"total":   total,
+	})
+}
+
+// listTasks support indexUids, statuses, types and limit filters, tasks listed newest first.
+func (s *Server) listTasks(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hit(r)
+
+	q := r.URL.Query()
+	limit, err := strconv.Atoi(q.Get("limit"))
+	if err != nil {
+		limit = 20
+	}
+
+	match := func(list, val string) bool {
+		return list == "" || slices.Contains(strings.Split(list, ","), val)
+	}
+
+	results := make([]*Task, 0)
+	for i := len(s.tasks) - 1; i >= 0 && len(results) < limit; i-- {
+		t := s.tasks[i]
+		if match(q.Get("indexUids"), t.IndexUID) && match(q.Get("statuses"), t.Status) && match(q.Get("types"), t.Type) {
+			results = append(results, t)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"results": results,
+		"limit":   limit,
+		"total":   len(results),
+	})
+}
+
+func (s *Server) task(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hit(r)
+
+	uid, err := strconv.ParseInt(r.PathValue("taskUid"), 10, 64)
+	if err != nil || uid < 0 || uid >= int64(len(s.tasks)) {
+		writeError(w, http.StatusNotFound, "task_not_found", "Task `"+r.PathValue("taskUid")+"` not found.")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.tasks[uid])
+}
+
+// search support filter of conditions joined by AND and sort by a single attribute.
+func (s *Server) search(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hit(r)
+
+	uid := r.PathValue("uid")
+	idx, ok := s.indexes[uid]
+	if !ok {
+		indexNotFound(w, uid)
+		return
+	}
+
+	query := struct {
+		Offset               int64    `json:"offset"`
+		Limit                int64    `json:"limit"`
+		AttributesToRetrieve []string `json:"attributesToRetrieve"`
+		Filter               string   `json:"filter"`
+		Sort                 []string `json:"sort"`
+	}{Limit: 20}
+
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	conds, err := parseFilter(query.Filter)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_search_filter", err.Error())
+		return
+	}
+
+	filterableAttrs, _ := idx.Settings["filterableAttributes"].([]string)
+	for _, c := range conds {
+		if !slices.Contains(filterableAttrs, c.attr) {
+			writeError(w, http.StatusBadRequest, "invalid_search_filter", "attribute `"+c.attr+"` is not filterable")
+			return
+		}
+	}
+
+	hits := make([]map[string]any, 0)
+	for _, doc := range idx.Documents {
+		if matchAll(doc, conds) {
+			hits = append(hits, doc)
+		}
+	}
+
+	if len(query.Sort) != 0 {
+		attr, order, _ := strings.Cut(query.Sort[0], ":")
+		sortableAttrs, _ := idx.Settings["sortableAttributes"].([]string)
+		if !slices.Contains(sortableAttrs, attr) {
+			writeError(w, http.StatusBadRequest, "invalid_search_sort", "attribute `"+attr+"` is not sortable")
+			return
+		}
+
+		slices.SortStableFunc(hits, func(a, b map[string]any) int {
+			if order == "desc" {
+				return compare(b[attr], a[attr])
+			}
+			return compare(a[attr], b[attr])
+		})
+	}
+
+	// hits beyond pagination.maxTotalHits of index are not reachable by search.
+	total := min(int64(len(hits)), _maxTotalHits)
+	results := make([]map[string]any, 0)
+
+	for i := query.Offset; i < total && i < query.Offset+query.Limit; i++ {
+		results = append(results, pick(hits[i], query.AttributesToRetrieve))
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"hits":               results,
+		"offset":             query.Offset,
+		"limit":              query.Limit,
+		"estimatedTotalHits": total,
+	})
+}
+
+type condition struct {
+	attr string
+	op   string
+	val  any
+}
+
+var _filterUnescaper = strings.NewReplacer(`\\`, `\`, `\"`, `"`)
+
+var conditionRegex = regexp.MustCompile(`^\s*([\w.]+)\s*(!=|>=|<=|=|>|<)\s*(.+?)\s*$`)
+
+func parseFilter(filter string) ([]condition, error) {
+	if filter == "" {
+		return nil, nil
+	}
+
+	filter = strings.NewReplacer("(", "", ")", "").Replace(filter)
+
+	conds := make([]condition, 0)
+	for _, part := range strings.Split(filter, " AND ") {
+		m := conditionRegex.FindStringSubmatch(part)
+		if m == nil {
+			return nil, errors.New("unsupported filter `" + part + "`")
+		}
+
+		c := condition{attr: m[1], op: m[2], val: m[3]}
+		if raw := m[3]; strings.HasPrefix(raw, `"`) && strings.HasSuffix(raw, `"`) {
+			c.val = _filterUnescaper.Replace(raw[1 : len(raw)-1])
+		} else if n, err := strconv.ParseFloat(raw, 64); err == nil {
+			c.val = n
+		}
+		conds = append(conds, c)
+	}
+
+	return conds, nil
+}
+
+func matchAll(doc map[string]any, conds []condition) bool {
+	for _, c := range conds {
+		val, ok := doc[c.attr]
+		if !ok {
+			return false
+		}
+
+		res := compare(val, c.val)
+		switch c.op {
+		case "=":
+			ok = res == 0
+		case "!=":
+			ok = res != 0
+		case ">":
+			ok = res > 0
+		case ">=":
+			ok = res >= 0
+		case "<":
+			ok = res < 0
+		case "<=":
+			ok = res <= 0
+		}
+
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// compare numbers by value and others by string format.
+func compare(a, b any) int {
+	if x, ok := number(a); ok {
+		if y, ok := number(b); ok {
+			return cmp.Compare(x, y)
+		}
+	}
+	return cmp.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
+
+// filterable reports whether first attribute of filter expression is filterable.
+func filterable(idx *Index, filter string) bool {
+	attrs, _ := idx.Settings["filterableAttributes"].([]string)
+	fields := strings.Fields(filter)
+	for _, a := range attrs {
+		if len(fields) != 0 && fields[0] == a {
+			return true
+		}
+	}
+	return false
+}
+
+func pick(doc map[string]any, fields []string) map[string]any {
+	if len(fields) == 0 || (len(fields) == 1 && fields[0] == "*") {
+		return doc
+	}
+
+	res := make(map[string]any, len(fields))
+	for _, f := range fields {
+		if v, ok := doc[f]; ok {
+			res[f] = v
+		}
+	}
+	return res
+}
+
+func indexNotFound(w http.ResponseWriter, uid string) {
+	writeError(w, http.StatusNotFound, "index_not_found", "Index `"+uid+"` not found.")
+}
+
+func writeError(w http.ResponseWriter, status int, code, msg string) {
+	writeJSON(w, status, map[string]string{
+		"message": msg,
+		"code":    code,
+		"type":    "invalid_request",
+		"link":    "https://docs.meilisearch.com/errors#" + code,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,157 @@
+package metrics
+
+import (
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const _namespace = "meilisitemap"
+
+var (
+	FetchDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: _namespace,
+		Name:      "meilisearch_fetch_duration_seconds",
+		Help:      "Latency of fetching a page of documents from Meilisearch.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"index"})
+
+	FetchPages = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: _namespace,
+		Name:      "meilisearch_fetch_pages_total",
+		Help:      "Pages of documents fetched from Meilisearch.",
+	}, []string{"index"})
+
+	DocumentsProcessed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: _namespace,
+		Name:      "documents_processed_total",
+		Help:      "Documents processed to build sitemap.",
+	}, []string{"index"})
+
+	DocumentsSkipped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: _namespace,
+		Name:      "documents_skipped_total",
+		Help:      "Documents skipped while building sitemap by reason.",
+	}, []string{"index", "reason"})
+
+	GenerationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: _namespace,
+		Name:      "generation_duration_seconds",
+		Help:      "Duration of sitemap generation run.",
+		Buckets:   []float64{.1, .5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600},
+	}, []string{"index"})
+
+	GenerationFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: _namespace,
+		Name:      "generation_failures_total",
+		Help:      "Failed sitemap generation runs.",
+	}, []string{"index"})
+
+	LastSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: _namespace,
+		Name:      "last_success_timestamp_seconds",
+		Help:      "Unix time of last successful sitemap generation.",
+	}, []string{"index"})
+
+	FileSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: _namespace,
+		Name:      "sitemap_file_size_bytes",
+		Help:      "Size of generated sitemap file.",
+	}, []string{"file"})
+
+	SchedulerLag = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: _namespace,
+		Name:      "scheduler_lag_seconds",
+		Help:      "Delay between scheduled time of job and its start.",
+		Buckets:   []float64{.001, .01, .1, .5, 1, 5, 30, 60},
+	})
+
+	SchedulerJobRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: _namespace,
+		Name:      "scheduler_job_runs_total",
+		Help:      "Runs of scheduler jobs by result, success, error, canceled or skipped by overlap.",
+	}, []string{"job", "result"})
+
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: _namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests served per sitemap file and status code.",
+	}, []string{"file", "code"})
+)
+
+// Registry contains all meilisitemap metrics with go and process collectors.
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		FetchDuration,
+		FetchPages,
+		DocumentsProcessed,
+		DocumentsSkipped,
+		GenerationDuration,
+		GenerationFailures,
+		LastSuccess,
+		FileSize,
+		SchedulerLag,
+		SchedulerJobRuns,
+		HTTPRequests,
+	)
+}
+
+// Handler serve metrics of Registry in prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Instrument count requests served by next per requested file of root, requests of
+// anything else like redirects, directories and missing files are counted as file
+// "other" to keep labels bounded.
+func Instrument(root string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		file := "other"
+		if (rec.code == http.StatusOK || rec.code == http.StatusNotModified) && isFile(root, r.URL.Path) {
+			file = FileLabel(r.URL.Path)
+		}
+
+		HTTPRequests.WithLabelValues(file, strconv.Itoa(rec.code)).Inc()
+	})
+}
+
+// SetFileSize set size of file written to store, file is path relative to store.
+func SetFileSize(file string, size int) {
+	FileSize.WithLabelValues(FileLabel(file)).Set(float64(size))
+}
+
+// FileLabel returns label of file relative to store like "sitemaps/movies.xml", same for
+// paths of requests and files.
+func FileLabel(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(name)), "/")
+}
+
+// isFile reports whether name is a regular file under root.
+func isFile(root, name string) bool {
+	fi, err := os.Stat(filepath.Join(root, filepath.FromSlash(path.Clean("/"+name))))
+	return err == nil && fi.Mode().IsRegular()
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.code = code
+	s.ResponseWriter.WriteHeader(code)
+}
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstrument(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, "sitemaps"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "sitemaps", "movies.xml"), []byte("<urlset></urlset>"), 0o644))
+
+	h := Instrument(root, http.FileServer(http.Dir(root)))
+
+	for _, path := range []string{"/sitemaps/movies.xml", "/sitemaps/movies.xml", "/a", "/b", "/sitemaps/", "/sitemaps"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(HTTPRequests.WithLabelValues("sitemaps/movies.xml", "200")))
+	assert.Equal(t, float64(2), testutil.ToFloat64(HTTPRequests.WithLabelValues("other", "404")))
+	// directory listing and redirect of directory are not labeled by path.
+	assert.Equal(t, float64(1), testutil.ToFloat64(HTTPRequests.WithLabelValues("other", "200")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(HTTPRequests.WithLabelValues("other", "301")))
+}
+
+func TestFileLabel(t *testing.T) {
+	for _, name := range []string{"sitemaps/movies.xml", "/sitemaps/movies.xml", "./sitemaps//movies.xml"} {
+		assert.Equal(t, "sitemaps/movies.xml", FileLabel(name), name)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	LastSuccess.WithLabelValues("movies").Set(1700000000)
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `meilisitemap_last_success_timestamp_seconds{index="movies"} 1.7e+09`)
+	assert.Contains(t, rec.Body.String(), "go_goroutines")
+}
@@ -0,0 +1,248 @@
+package preflight
+
+import (
+	"context"
+	"errors"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/Ja7ad/meilisitemap/utils"
+	"github.com/meilisearch/meilisearch-go"
+)
+
+const _sampleSize = 20
+
+var ErrFatalMismatch = errors.New("field map does not match index schema")
+
+// filterAttrRegex match attribute name at left side of filter condition.
+var filterAttrRegex = regexp.MustCompile(`(?i)(?:^|[(&|]|\bAND\b|\bOR\b|\bNOT\b)\s*([A-Za-z0-9_.]+)\s*(?:!=|>=|<=|=|>|<|\bIN\b|\bNOT\s+IN\b|\bEXISTS\b|\bNOT\s+EXISTS\b|\bIS\b|[0-9.]+\s+TO\b)`)
+
+// Run verify field map of every sitemap against live index schema, settings and sample of documents.
+func Run(ctx context.Context, meili meilisearch.ServiceManager, sitemaps map[string]*config.SitemapConfig) *Report {
+	report := new(Report)
+
+	names := make([]string, 0, len(sitemaps))
+	for name := range sitemaps {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		report.Indexes = append(report.Indexes, checkIndex(ctx, meili, name, sitemaps[name]))
+	}
+
+	return report
+}
+
+func checkIndex(ctx context.Context, meili meilisearch.ServiceManager, name string, cfg *config.SitemapConfig) *IndexReport {
+	ir := &IndexReport{Index: name, Checks: make([]*Check, 0)}
+
+	src := cfg.SourceIndex(name)
+
+	if _, err := meili.GetIndexWithContext(ctx, src); err != nil {
+		ir.add(LevelFatal, "index", src, "failed to get index: %v", err)
+		return ir
+	}
+
+	idx := meili.Index(src)
+
+	settings, err := idx.GetSettingsWithContext(ctx)
+	if err != nil {
+		ir.add(LevelFatal, "index", "", "failed to get index settings: %v", err)
+		return ir
+	}
+
+	stats, err := idx.GetStatsWithContext(ctx)
+	if err != nil {
+		ir.add(LevelFatal, "index", "", "failed to get index stats: %v", err)
+		return ir
+	}
+
+	ir.Documents = stats.NumberOfDocuments
+
+	query := &meilisearch.DocumentsQuery{Limit: _sampleSize}
+
+	// sample documents without filter when filter is not usable,
+	// field map still can be verified.
+	if cfg.Filter != "" && checkFilter(ir, cfg.Filter, settings.FilterableAttributes) {
+		query.Filter = cfg.Filter
+	}
+
+	if cfg.Fetch != nil && cfg.Fetch.Strategy == config.FetchCursor {
+		checkCursor(ir, cfg, settings)
+	}
+
+	docs := new(meilisearch.DocumentsResult)
+	if err := idx.GetDocumentsWithContext(ctx, query, docs); err != nil {
+		ir.add(LevelFatal, "filter", cfg.Filter, "failed to get sample documents: %v", err)
+		return ir
+	}
+
+	ir.Sampled = len(docs.Results)
+
+	if stats.NumberOfDocuments == 0 || ir.Sampled == 0 {
+		ir.add(LevelWarning, "index", "", "no documents to verify field map")
+		return ir
+	}
+
+	for _, f := range cfg.FieldMap.Fields() {
+		checkField(ir, f, settings.DisplayedAttributes, stats, docs.Results)
+	}
+
+	return ir
+}
+
+func checkFilter(ir *IndexReport, filter string, filterable []string) bool {
+	ok := true
+
+	for _, m := range filterAttrRegex.FindAllStringSubmatch(filter, -1) {
+		attr := m[1]
+		if !containsAttr(filterable, attr) {
+			ir.add(LevelFatal, "filter", attr, "attribute is not in filterableAttributes of index")
+			ok = false
+			continue
+		}
+		ir.add(LevelOK, "filter", attr, "")
+	}
+
+	return ok
+}
+
+// checkCursor verify cursor key of fetch is filterable and sortable.
+func checkCursor(ir *IndexReport, cfg *config.SitemapConfig, settings *meilisearch.Settings) {
+	key := cfg.Fetch.CursorKey
+	if key == "" {
+		key = cfg.FieldMap.UniqueField
+	}
+
+	switch {
+	case !containsAttr(settings.FilterableAttributes, key):
+		ir.add(LevelFatal, "fetch.cursor_key", key, "attribute is not in filterableAttributes of index")
+	case !containsAttr(settings.SortableAttributes, key):
+		ir.add(LevelFatal, "fetch.cursor_key", key, "attribute is not in sortableAttributes of index")
+	default:
+		ir.add(LevelOK, "fetch.cursor_key", key, "")
+	}
+}
+
+func checkField(ir *IndexReport, f config.Field, displayed []string, stats *meilisearch.StatsIndex, docs []map[string]any) {
+	path := "field_map." + f.Path
+	attr, _, _ := strings.Cut(f.Key, ".")
+
+	if len(displayed) != 0 && !containsAttr(displayed, attr) {
+		ir.add(LevelFatal, path, f.Key, "attribute %q is not in displayedAttributes of index", attr)
+		return
+	}
+
+	count, ok := stats.FieldDistribution[attr]
+	if !ok || count == 0 {
+		ir.add(LevelFatal, path, f.Key, "attribute %q not exists in any document of index", attr)
+		return
+	}
+
+	present, usable := 0, 0
+	var badType any
+
+	for _, doc := range docs {
+		val := utils.PickByNestedKey(doc, f.Key)
+		if val == nil {
+			continue
+		}
+
+		present++
+
+		if readable(f.Kind, val) {
+			usable++
+		} else if badType == nil {
+			badType = val
+		}
+	}
+
+	switch {
+	case present == 0:
+		level := LevelWarning
+		if f.Kind == config.FieldUnique {
+			level = LevelFatal
+		}
+		ir.add(level, path, f.Key, "not found in %d sampled documents", len(docs))
+	case usable == 0 && f.Critical:
+		ir.add(LevelFatal, path, f.Key, "unusable value type %T", badType)
+	case usable < present:
+		ir.add(LevelWarning, path, f.Key, "unusable value type %T in %d of %d sampled documents",
+			badType, present-usable, present)
+	case count < stats.NumberOfDocuments:
+		ir.add(LevelWarning, path, f.Key, "attribute %q missing in %d of %d documents",
+			attr, stats.NumberOfDocuments-count, stats.NumberOfDocuments)
+	case present < len(docs):
+		ir.add(LevelWarning, path, f.Key, "missing in %d of %d sampled documents", len(docs)-present, len(docs))
+	default:
+		ir.add(LevelOK, path, f.Key, "")
+	}
+}
+
+// readable reports whether val of document can be read as kind.
+func readable(kind config.FieldKind, val any) bool {
+	switch kind {
+	case config.FieldUnique:
+		switch v := val.(type) {
+		case string:
+			return strings.TrimSpace(v) != ""
+		case int, int64:
+			return true
+		case float64:
+			return v == math.Trunc(v)
+		}
+	case config.FieldString:
+		_, ok := val.(string)
+		return ok
+	case config.FieldScalar:
+		switch val.(type) {
+		case map[string]any, []any:
+			return false
+		}
+		return true
+	case config.FieldDate:
+		switch v := val.(type) {
+		case string:
+			_, err := time.Parse(time.RFC3339, v)
+			return err == nil
+		case time.Time, int, int64, float64:
+			return true
+		}
+	case config.FieldBool:
+		_, ok := val.(bool)
+		return ok
+	case config.FieldList:
+		if _, ok := val.(string); ok {
+			return true
+		}
+		return readable(config.FieldArray, val)
+	case config.FieldArray:
+		switch v := val.(type) {
+		case []string:
+			return true
+		case []any:
+			for _, item := range v {
+				if _, ok := item.(string); !ok {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// containsAttr reports whether attr or one of its parent objects is in attrs.
+func containsAttr(attrs []string, attr string) bool {
+	for _, a := range attrs {
+		if a == "*" || a == attr || strings.HasPrefix(attr, a+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,245 @@
+package preflight
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/Ja7ad/meilisitemap/internal/meilitest"
+	"github.com/meilisearch/meilisearch-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var moviesForTest = []map[string]any{
+	{
+		"id":         1,
+		"title":      "Anatomy of a Fall",
+		"created_at": "2024-01-02T15:04:05Z",
+		"genre":      "drama",
+		"poster":     map[string]any{"file": "anatomy.jpg"},
+		"keywords":   []any{"court", "drama"},
+	},
+	{
+		"id":         2,
+		"title":      "Past Lives",
+		"created_at": "2024-02-02T15:04:05Z",
+		"genre":      "drama",
+		"poster":     map[string]any{"file": "past-lives.jpg"},
+		"keywords":   []any{"love", 2023},
+	},
+}
+
+func TestRun(t *testing.T) {
+	srv := meilitest.New()
+	defer srv.Close()
+
+	srv.AddIndex("movies", moviesForTest, map[string]any{
+		"displayedAttributes":  []string{"*"},
+		"filterableAttributes": []string{"genre", "id"},
+		"sortableAttributes":   []string{"id"},
+	})
+	srv.AddIndex("hidden", moviesForTest, map[string]any{
+		"displayedAttributes":  []string{"id", "title"},
+		"filterableAttributes": []string{},
+	})
+
+	meili := meilisearch.New(srv.URL)
+
+	tests := []struct {
+		name     string
+		index    string
+		cfg      *config.SitemapConfig
+		expected map[string]Level
+	}{
+		{
+			name:  "valid field map",
+			index: "movies",
+			cfg: &config.SitemapConfig{
+				Filter: "genre = drama",
+				FieldMap: &config.FieldMapConfig{
+					UniqueField: "id",
+					LastMod:     "created_at",
+					Image: &config.ImageConfig{
+						Loc:   "poster.file|https://cdn.example.com/images",
+						Title: "title",
+					},
+				},
+			},
+			expected: map[string]Level{
+				"filter":                 LevelOK,
+				"field_map.unique_field": LevelOK,
+				"field_map.lastmod":      LevelOK,
+				"field_map.image.loc":    LevelOK,
+				"field_map.image.title":  LevelOK,
+			},
+		},
+		{
+			name:  "typo in fields",
+			index: "movies",
+			cfg: &config.SitemapConfig{
+				Filter: "year > 2000",
+				FieldMap: &config.FieldMapConfig{
+					UniqueField: "slug",
+					LastMod:     "title",
+					Image: &config.ImageConfig{
+						Loc: "poster.url",
+					},
+					News: &config.NewsConfig{
+						Keywords: "keywords",
+					},
+				},
+			},
+			expected: map[string]Level{
+				"filter":                  LevelFatal,
+				"field_map.unique_field":  LevelFatal,
+				"field_map.lastmod":       LevelFatal,
+				"field_map.image.loc":     LevelWarning,
+				"field_map.news.keywords": LevelWarning,
+			},
+		},
+		{
+			name:  "feed fields",
+			index: "movies",
+			cfg: &config.SitemapConfig{
+				FieldMap: &config.FieldMapConfig{
+					UniqueField: "id",
+					LastMod:     "created_at",
+					Feed: &config.FeedItemConfig{
+						Title:      "title",
+						Summary:    "poster.caption",
+						Categories: "genre",
+					},
+				},
+			},
+			expected: map[string]Level{
+				"field_map.unique_field":    LevelOK,
+				"field_map.lastmod":         LevelOK,
+				"field_map.feed.title":      LevelOK,
+				"field_map.feed.summary":    LevelWarning,
+				"field_map.feed.categories": LevelOK,
+			},
+		},
+		{
+			name:  "cursor key",
+			index: "movies",
+			cfg: &config.SitemapConfig{
+				Fetch: &config.IndexFetch{Strategy: config.FetchCursor},
+				FieldMap: &config.FieldMapConfig{
+					UniqueField: "id",
+					LastMod:     "created_at",
+				},
+			},
+			expected: map[string]Level{
+				"fetch.cursor_key":       LevelOK,
+				"field_map.unique_field": LevelOK,
+				"field_map.lastmod":      LevelOK,
+			},
+		},
+		{
+			name:  "cursor key not sortable",
+			index: "movies",
+			cfg: &config.SitemapConfig{
+				Fetch: &config.IndexFetch{Strategy: config.FetchCursor, CursorKey: "genre"},
+				FieldMap: &config.FieldMapConfig{
+					UniqueField: "id",
+					LastMod:     "created_at",
+				},
+			},
+			expected: map[string]Level{
+				"fetch.cursor_key":       LevelFatal,
+				"field_map.unique_field": LevelOK,
+				"field_map.lastmod":      LevelOK,
+			},
+		},
+		{
+			name:  "hidden attributes",
+			index: "hidden",
+			cfg: &config.SitemapConfig{
+				FieldMap: &config.FieldMapConfig{
+					UniqueField: "id",
+					LastMod:     "created_at",
+				},
+			},
+			expected: map[string]Level{
+				"field_map.unique_field": LevelOK,
+				"field_map.lastmod":      LevelFatal,
+			},
+		},
+		{
+			name:  "missing index",
+			index: "series",
+			cfg: &config.SitemapConfig{
+				FieldMap: &config.FieldMapConfig{UniqueField: "id"},
+			},
+			expected: map[string]Level{
+				"index": LevelFatal,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Run(context.Background(), meili, map[string]*config.SitemapConfig{tt.index: tt.cfg})
+			require.Len(t, report.Indexes, 1)
+
+			levels := make(map[string]Level)
+			for _, c := range report.Indexes[0].Checks {
+				levels[c.Field] = c.Level
+			}
+			assert.Equal(t, tt.expected, levels)
+
+			fatal := false
+			for _, l := range tt.expected {
+				fatal = fatal || l == LevelFatal
+			}
+
+			if fatal {
+				assert.ErrorIs(t, report.Err(), ErrFatalMismatch)
+				assert.NotEmpty(t, report.Fatal())
+			} else {
+				assert.NoError(t, report.Err())
+			}
+		})
+	}
+}
+
+func TestReadable(t *testing.T) {
+	tests := []struct {
+		kind config.FieldKind
+		val  any
+		want bool
+	}{
+		{kind: config.FieldList, val: "drama", want: true},
+		{kind: config.FieldList, val: []any{"drama", "thriller"}, want: true},
+		{kind: config.FieldList, val: []any{"drama", 1}, want: false},
+		{kind: config.FieldList, val: 1, want: false},
+		{kind: config.FieldScalar, val: 2023, want: true},
+		{kind: config.FieldScalar, val: []any{"drama"}, want: false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, readable(tt.kind, tt.val), "%v as %d", tt.val, tt.kind)
+	}
+}
+
+func TestFilterAttributes(t *testing.T) {
+	tests := []struct {
+		filter   string
+		expected []string
+	}{
+		{"genre = horror", []string{"genre"}},
+		{"genre = horror AND imdb_rate > 5", []string{"genre", "imdb_rate"}},
+		{"(genre IN [horror, drama] OR year 2000 TO 2010) AND NOT director.name EXISTS", []string{"genre", "year", "director.name"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			attrs := make([]string, 0)
+			for _, m := range filterAttrRegex.FindAllStringSubmatch(tt.filter, -1) {
+				attrs = append(attrs, m[1])
+			}
+			assert.Equal(t, tt.expected, attrs)
+		})
+	}
+}
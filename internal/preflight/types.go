@@ -0,0 +1,102 @@
+package preflight
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Level uint8
+
+const (
+	LevelOK Level = iota
+	LevelWarning
+	LevelFatal
+)
+
+// Report is result of preflight for all configured sitemaps.
+type Report struct {
+	Indexes []*IndexReport `json:"indexes"`
+}
+
+type IndexReport struct {
+	Index     string   `json:"index"`
+	Documents int64    `json:"documents"`
+	Sampled   int      `json:"sampled"`
+	Checks    []*Check `json:"checks"`
+}
+
+// Check is result of verify one mapped field or setting of index.
+type Check struct {
+	Field   string `json:"field"`         // Field is config path, for example field_map.image.loc
+	Key     string `json:"key,omitempty"` // Key is document key path of field
+	Level   Level  `json:"level"`         // Level of check result
+	Message string `json:"message,omitempty"`
+}
+
+func (l Level) String() string {
+	switch l {
+	case LevelOK:
+		return "ok"
+	case LevelWarning:
+		return "warning"
+	case LevelFatal:
+		return "fatal"
+	default:
+		return ""
+	}
+}
+
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+func (c *Check) String() string {
+	s := c.Field
+	if c.Key != "" {
+		s = fmt.Sprintf("%s (%s)", s, c.Key)
+	}
+	if c.Message != "" {
+		s += ": " + c.Message
+	}
+	return s
+}
+
+// Fatal returns all fatal checks of report.
+func (r *Report) Fatal() []*Check {
+	res := make([]*Check, 0)
+	for _, idx := range r.Indexes {
+		for _, c := range idx.Checks {
+			if c.Level == LevelFatal {
+				res = append(res, c)
+			}
+		}
+	}
+	return res
+}
+
+// Err returns error if report contains any fatal check.
+func (r *Report) Err() error {
+	msgs := make([]string, 0)
+	for _, idx := range r.Indexes {
+		for _, c := range idx.Checks {
+			if c.Level == LevelFatal {
+				msgs = append(msgs, idx.Index+": "+c.String())
+			}
+		}
+	}
+
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrFatalMismatch, strings.Join(msgs, "; "))
+}
+
+func (ir *IndexReport) add(level Level, field, key, format string, args ...any) {
+	ir.Checks = append(ir.Checks, &Check{
+		Field:   field,
+		Key:     key,
+		Level:   level,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
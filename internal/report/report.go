@@ -0,0 +1,153 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FileName of report in sitemap store.
+const FileName = "report.json"
+
+// Report is result of latest generation run of every index.
+type Report struct {
+	UpdatedAt time.Time `json:"updated_at"`
+	Indexes   []*Index  `json:"indexes"`
+}
+
+// Index is result of a single generation run of index sitemap.
+type Index struct {
+	Index       string         `json:"index"`
+	Source      string         `json:"source,omitempty"`   // Source index of documents when differ from index
+	TaskUID     *int64         `json:"task_uid,omitempty"` // TaskUID of last task of source index applied to documents
+	File        string         `json:"file"`
+	StartedAt   time.Time      `json:"started_at"`
+	DurationMS  int64          `json:"duration_ms"`
+	Documents   int            `json:"documents_fetched"`
+	URLs        int            `json:"urls_emitted"`
+	Skipped     int            `json:"documents_skipped"`
+	SkipReasons map[string]int `json:"skip_reasons"`
+	Duplicates  int            `json:"duplicates_dropped"`
+	Added       int            `json:"urls_added"`    // Added urls since previous run
+	Removed     int            `json:"urls_removed"`  // Removed urls of documents deleted since previous run
+	Retained    int            `json:"urls_retained"` // Retained urls of removed documents in grace period
+	Bytes       int            `json:"bytes_written"`
+	Changed     bool           `json:"changed"`
+	Error       string         `json:"error,omitempty"`
+}
+
+// Recorder keeps latest run of every index and persist report to store after each run.
+type Recorder struct {
+	mu     sync.RWMutex
+	path   string
+	report *Report
+}
+
+func NewIndex(index, file string) *Index {
+	return &Index{
+		Index:       index,
+		File:        file,
+		StartedAt:   time.Now(),
+		SkipReasons: make(map[string]int),
+	}
+}
+
+// Finish set duration of run and error if run failed.
+func (i *Index) Finish(err error) {
+	i.DurationMS = time.Since(i.StartedAt).Milliseconds()
+	if err != nil {
+		i.Error = err.Error()
+	}
+}
+
+func NewRecorder(storePath string) *Recorder {
+	return &Recorder{
+		path:   filepath.Join(storePath, FileName),
+		report: &Report{Indexes: make([]*Index, 0)},
+	}
+}
+
+// Record replace previous run of index and write report to store.
+func (r *Recorder) Record(idx *Index) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	replaced := false
+	for i, prev := range r.report.Indexes {
+		if prev.Index == idx.Index {
+			r.report.Indexes[i] = idx
+			replaced = true
+			break
+		}
+	}
+
+	if !replaced {
+		r.report.Indexes = append(r.report.Indexes, idx)
+		sort.Slice(r.report.Indexes, func(i, j int) bool {
+			return r.report.Indexes[i].Index < r.report.Indexes[j].Index
+		})
+	}
+
+	r.report.UpdatedAt = time.Now()
+
+	b, err := json.MarshalIndent(r.report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write to temp file then rename, readers never see a partial report.
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return fmt.Errorf("error writing report %s: %w", tmp, err)
+	}
+
+	return os.Rename(tmp, r.path)
+}
+
+// Report returns copy of current report.
+func (r *Recorder) Report() *Report {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cp := *r.report
+	cp.Indexes = append([]*Index(nil), r.report.Indexes...)
+	return &cp
+}
+
+// Load read report from store.
+func Load(storePath string) (*Report, error) {
+	b, err := os.ReadFile(filepath.Join(storePath, FileName))
+	if err != nil {
+		return nil, err
+	}
+
+	rep := new(Report)
+	if err := json.Unmarshal(b, rep); err != nil {
+		return nil, err
+	}
+
+	return rep, nil
+}
+
+// Handler serve report of store as json, 404 if no run recorded yet.
+func Handler(storePath string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		b, err := os.ReadFile(filepath.Join(storePath, FileName))
+		if errors.Is(err, os.ErrNotExist) {
+			http.Error(w, "no report yet", http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(b)
+	})
+}
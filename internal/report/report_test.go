@@ -0,0 +1,57 @@
+package report
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	store := t.TempDir()
+	r := NewRecorder(store)
+
+	series := NewIndex("series", "sitemaps/series.xml")
+	series.Finish(errors.New("index not found"))
+	require.NoError(t, r.Record(series))
+
+	movies := NewIndex("movies", "sitemaps/movies.xml")
+	movies.URLs = 10
+	movies.Finish(nil)
+	require.NoError(t, r.Record(movies))
+
+	again := NewIndex("movies", "sitemaps/movies.xml")
+	again.URLs = 12
+	again.Finish(nil)
+	require.NoError(t, r.Record(again))
+
+	rep, err := Load(store)
+	require.NoError(t, err)
+	require.Len(t, rep.Indexes, 2)
+	assert.Equal(t, "movies", rep.Indexes[0].Index)
+	assert.Equal(t, 12, rep.Indexes[0].URLs)
+	assert.Equal(t, "index not found", rep.Indexes[1].Error)
+	assert.Len(t, r.Report().Indexes, 2)
+}
+
+func TestHandler(t *testing.T) {
+	store := t.TempDir()
+	h := Handler(store)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/report", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	idx := NewIndex("movies", "sitemaps/movies.xml")
+	idx.Finish(nil)
+	require.NoError(t, NewRecorder(store).Record(idx))
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/report", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), `"index": "movies"`)
+}
@@ -0,0 +1,170 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/Ja7ad/meilisitemap/config"
+)
+
+const (
+	_defaultMaxAttempts      = 5
+	_defaultInitialBackoff   = 500 * time.Millisecond
+	_defaultMaxBackoff       = 30 * time.Second
+	_defaultJitter           = 0.2
+	_defaultTimeout          = 30 * time.Second
+	_defaultBreakerThreshold = 5
+	_defaultBreakerCooldown  = 30 * time.Second
+
+	_multiplier = 2
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// Policy retry failed operations with exponential backoff and jitter,
+// every attempt has its own timeout and fails fast while breaker is open.
+type Policy struct {
+	MaxAttempts    int // MaxAttempts of operation, zero retry until context done
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Jitter         float64       // Jitter is random fraction of backoff added or removed, 0..1
+	Timeout        time.Duration // Timeout of every attempt, zero without timeout
+	Breaker        *Breaker      // Breaker shared between operations, nil disable it
+
+	// Retryable reports err is temporary, nil treats every error as retryable.
+	Retryable func(err error) bool
+	// OnRetry called after failed attempt before waiting for next one.
+	OnRetry func(attempt int, err error, wait time.Duration)
+}
+
+// NewPolicy make policy of config, zero values of config use defaults except max attempts and
+// jitter which are defaulted only when unset.
+func NewPolicy(cfg *config.RetryConfig) *Policy {
+	if cfg == nil {
+		cfg = new(config.RetryConfig)
+	}
+
+	p := &Policy{
+		MaxAttempts:    _defaultMaxAttempts,
+		InitialBackoff: orDefault(cfg.InitialBackoff, _defaultInitialBackoff),
+		MaxBackoff:     orDefault(cfg.MaxBackoff, _defaultMaxBackoff),
+		Jitter:         _defaultJitter,
+		Timeout:        orDefault(cfg.Timeout, _defaultTimeout),
+	}
+
+	if cfg.MaxAttempts != nil {
+		p.MaxAttempts = *cfg.MaxAttempts
+	}
+	if cfg.Jitter != nil {
+		p.Jitter = *cfg.Jitter
+	}
+
+	p.Breaker = NewBreaker(
+		orDefault(cfg.BreakerThreshold, _defaultBreakerThreshold),
+		orDefault(cfg.BreakerCooldown, _defaultBreakerCooldown),
+	)
+
+	return p
+}
+
+// Do run fn until it succeeds, returns not retryable error, attempts exhausted or ctx done.
+func (p *Policy) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	var err error
+
+	for attempt := 1; p.MaxAttempts <= 0 || attempt <= p.MaxAttempts; attempt++ {
+		if p.Breaker != nil {
+			if bErr := p.Breaker.Allow(); bErr != nil {
+				if err != nil {
+					return fmt.Errorf("%w, last error: %w", bErr, err)
+				}
+				return bErr
+			}
+		}
+
+		err = p.attempt(ctx, fn)
+
+		retryable := err != nil && ctx.Err() == nil && (p.Retryable == nil || p.Retryable(err))
+
+		// only temporary failures count for breaker, a not retryable error
+		// means remote is reachable and answering. Attempts ended by ctx of
+		// caller tell nothing about remote and are not recorded.
+		if p.Breaker != nil {
+			switch {
+			case ctx.Err() != nil:
+				p.Breaker.Release()
+			case retryable:
+				p.Breaker.Record(err)
+			default:
+				p.Breaker.Record(nil)
+			}
+		}
+
+		if err == nil {
+			return nil
+		}
+
+		if !retryable {
+			return err
+		}
+
+		if p.MaxAttempts > 0 && attempt == p.MaxAttempts {
+			break
+		}
+
+		wait := p.backoff(attempt)
+		if p.OnRetry != nil {
+			p.OnRetry(attempt, err, wait)
+		}
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return fmt.Errorf("%w, last error: %w", ctx.Err(), err)
+		case <-t.C:
+		}
+	}
+
+	return fmt.Errorf("giving up after %d attempts: %w", p.MaxAttempts, err)
+}
+
+func (p *Policy) attempt(ctx context.Context, fn func(ctx context.Context) error) error {
+	if p.Timeout <= 0 {
+		return fn(ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.Timeout)
+	defer cancel()
+
+	return fn(ctx)
+}
+
+// backoff returns wait after failed attempt, initial backoff doubled every attempt
+// up to max backoff, with random jitter.
+func (p *Policy) backoff(attempt int) time.Duration {
+	d := float64(p.InitialBackoff)
+	for i := 1; i < attempt && d < float64(p.MaxBackoff); i++ {
+		d *= _multiplier
+	}
+
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (rand.Float64()*2 - 1)
+	}
+
+	return time.Duration(d)
+}
+
+func orDefault[T comparable](v, def T) T {
+	var zero T
+	if v == zero {
+		return def
+	}
+	return v
+}
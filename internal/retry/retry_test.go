@@ -0,0 +1,195 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errTemporary = errors.New("temporary")
+
+func newTestPolicy() *Policy {
+	return &Policy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+	}
+}
+
+func TestPolicyDo(t *testing.T) {
+	errPermanent := errors.New("permanent")
+
+	tests := []struct {
+		name     string
+		failures int
+		err      error
+		wantErr  error
+		attempts int
+	}{
+		{name: "success", attempts: 1},
+		{name: "success after retries", failures: 2, err: errTemporary, attempts: 3},
+		{name: "attempts exhausted", failures: 5, err: errTemporary, wantErr: errTemporary, attempts: 3},
+		{name: "not retryable", failures: 5, err: errPermanent, wantErr: errPermanent, attempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPolicy()
+			p.Retryable = func(err error) bool { return !errors.Is(err, errPermanent) }
+
+			retries := 0
+			p.OnRetry = func(int, error, time.Duration) { retries++ }
+
+			attempts := 0
+			err := p.Do(context.Background(), func(context.Context) error {
+				attempts++
+				if attempts <= tt.failures {
+					return tt.err
+				}
+				return nil
+			})
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.attempts, attempts)
+			assert.Equal(t, min(tt.attempts, p.MaxAttempts)-1, retries)
+		})
+	}
+}
+
+func TestPolicyTimeout(t *testing.T) {
+	p := newTestPolicy()
+	p.Timeout = 10 * time.Millisecond
+
+	err := p.Do(context.Background(), func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestPolicyCanceled(t *testing.T) {
+	p := newTestPolicy()
+	p.MaxAttempts = 0
+	p.InitialBackoff = time.Hour
+	p.MaxBackoff = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := p.Do(ctx, func(context.Context) error { return errTemporary })
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, err, errTemporary)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestNewPolicy(t *testing.T) {
+	p := NewPolicy(nil)
+	assert.Equal(t, _defaultMaxAttempts, p.MaxAttempts)
+	assert.Equal(t, _defaultJitter, p.Jitter)
+
+	// zero of max attempts and jitter is configured, not unset.
+	zero, noJitter := 0, 0.0
+	p = NewPolicy(&config.RetryConfig{MaxAttempts: &zero, Jitter: &noJitter})
+	assert.Zero(t, p.MaxAttempts)
+	assert.Zero(t, p.Jitter)
+}
+
+func TestPolicyBackoff(t *testing.T) {
+	jitter := 0.5
+	p := NewPolicy(&config.RetryConfig{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Jitter:         &jitter,
+	})
+
+	for attempt, want := range map[int]time.Duration{
+		1:  100 * time.Millisecond,
+		2:  200 * time.Millisecond,
+		3:  400 * time.Millisecond,
+		10: time.Second,
+	} {
+		got := p.backoff(attempt)
+		assert.GreaterOrEqual(t, got, want/2, attempt)
+		assert.LessOrEqual(t, got, want*3/2, attempt)
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	b := NewBreaker(2, 20*time.Millisecond)
+
+	require.NoError(t, b.Allow())
+	b.Record(errTemporary)
+	assert.Equal(t, StateClosed, b.State())
+
+	b.Record(errTemporary)
+	assert.Equal(t, StateOpen, b.State())
+	assert.ErrorIs(t, b.Allow(), ErrCircuitOpen)
+
+	time.Sleep(25 * time.Millisecond)
+
+	require.NoError(t, b.Allow())
+	assert.Equal(t, StateHalfOpen, b.State())
+	assert.ErrorIs(t, b.Allow(), ErrCircuitOpen, "only single trial call allowed")
+
+	b.Record(errTemporary)
+	assert.Equal(t, StateOpen, b.State())
+
+	time.Sleep(25 * time.Millisecond)
+
+	require.NoError(t, b.Allow())
+	b.Record(nil)
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestPolicyBreakerOpen(t *testing.T) {
+	p := newTestPolicy()
+	p.Breaker = NewBreaker(2, time.Hour)
+
+	calls := 0
+	err := p.Do(context.Background(), func(context.Context) error {
+		calls++
+		return errTemporary
+	})
+
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.ErrorIs(t, err, errTemporary)
+	assert.Equal(t, 2, calls)
+
+	err = p.Do(context.Background(), func(context.Context) error {
+		calls++
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 2, calls)
+}
+
+func TestPolicyBreakerCanceled(t *testing.T) {
+	p := newTestPolicy()
+	p.Breaker = NewBreaker(1, 10*time.Millisecond)
+
+	p.Breaker.Record(errTemporary)
+	require.Equal(t, StateOpen, p.Breaker.State())
+	time.Sleep(15 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := p.Do(ctx, func(ctx context.Context) error {
+		cancel()
+		return ctx.Err()
+	})
+	require.ErrorIs(t, err, context.Canceled)
+
+	// canceled trial neither closes breaker nor blocks next trial.
+	assert.Equal(t, StateHalfOpen, p.Breaker.State())
+	require.NoError(t, p.Breaker.Allow())
+}
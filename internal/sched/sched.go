@@ -2,69 +2,477 @@ package sched
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/Ja7ad/meilisitemap/config"
 	"github.com/Ja7ad/meilisitemap/internal/logger"
+	"github.com/Ja7ad/meilisitemap/internal/metrics"
+	"github.com/robfig/cron/v3"
 )
 
+var (
+	ErrInvalidCron = errors.New("invalid cron expression")
+	ErrJobExists   = errors.New("job already exists")
+	ErrJobNotFound = errors.New("job not found")
+	ErrJobRunning  = errors.New("job is running")
+	ErrNotRunning  = errors.New("job is not running")
+	ErrStopped     = errors.New("scheduler is stopped")
+)
+
+// Sched run named jobs by their schedule, jobs can be added and removed while it's running.
 type Sched struct {
-	jobs []*job
-	ctx  context.Context
-	wg   sync.WaitGroup
-	log  logger.Logger
+	mu      sync.Mutex
+	jobs    map[string]*job
+	seq     int
+	ctx     context.Context
+	cancel  context.CancelFunc
+	started bool
+	stopped bool
+	loops   sync.WaitGroup // loops scheduling jobs
+	runs    sync.WaitGroup // running job functions
+	log     logger.Logger
+}
+
+// JobFunc is function of job, ctx is canceled when job removed or scheduler stopped.
+type JobFunc func(ctx context.Context) error
+
+// Schedule returns next run of job after t.
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+// Every run job at fixed interval.
+type Every time.Duration
+
+func (e Every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+// Manual never run job by schedule, job runs only when triggered.
+var Manual Schedule = manual{}
+
+type manual struct{}
+
+func (manual) Next(time.Time) time.Time {
+	return time.Time{}
+}
+
+// Cron parse standard cron expression of five fields or descriptor like @daily, times of
+// expression are in loc, nil loc is local timezone.
+func Cron(expr string, loc *time.Location) (Schedule, error) {
+	s, err := cron.ParseStandard(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %w", ErrInvalidCron, expr, err)
+	}
+
+	if spec, ok := s.(*cron.SpecSchedule); ok && loc != nil {
+		spec.Location = loc
+	}
+
+	return s, nil
+}
+
+// Deadline returns time of nth run of schedule after last, irregular schedules like
+// weekdays only cron count runs instead of durations.
+func Deadline(s Schedule, last time.Time, n int) time.Time {
+	t := last
+	for range n {
+		t = s.Next(t)
+	}
+	return t
+}
+
+// JobStatus is state of job returned by introspection API.
+type JobStatus struct {
+	Name       string     `json:"name"`
+	Running    bool       `json:"running"`
+	Queued     bool       `json:"queued"`
+	Runs       int        `json:"runs"`
+	Failures   int        `json:"failures"`
+	Skipped    int        `json:"skipped"`
+	LastRun    *time.Time `json:"last_run,omitempty"`
+	DurationMS int64      `json:"last_duration_ms"`
+	LastError  string     `json:"last_error,omitempty"`
+	NextRun    *time.Time `json:"next_run,omitempty"`
 }
 
 type job struct {
-	interval time.Duration
-	fn       func()
+	name       string
+	schedule   Schedule
+	jitter     time.Duration
+	runAtStart bool
+	overlap    config.Overlap
+	fn         JobFunc
+	ctx        context.Context
+	cancel     context.CancelFunc
+
+	mu        sync.Mutex
+	status    JobStatus
+	cancelRun context.CancelFunc // cancelRun cancel context of running run
+}
+
+type JobOption func(j *job)
+
+// WithJitter delay every run by random duration up to d, so jobs of same schedule don't run together.
+func WithJitter(d time.Duration) JobOption {
+	return func(j *job) {
+		j.jitter = d
+	}
+}
+
+// WithRunAtStart run job once when scheduler started, before first scheduled run.
+func WithRunAtStart() JobOption {
+	return func(j *job) {
+		j.runAtStart = true
+	}
+}
+
+// WithOverlap set policy of runs triggered while job is running, default is config.OverlapSkip.
+func WithOverlap(o config.Overlap) JobOption {
+	return func(j *job) {
+		j.overlap = o
+	}
 }
 
 func New(ctx context.Context, log logger.Logger) *Sched {
 	s := new(Sched)
-	s.ctx = ctx
+	s.ctx, s.cancel = context.WithCancel(ctx)
 	s.log = log
-	s.jobs = make([]*job, 0)
+	s.jobs = make(map[string]*job)
 	return s
 }
 
+// AddJob add unnamed job run every interval.
 func (s *Sched) AddJob(jobFunc func(), interval time.Duration) {
-	s.jobs = append(s.jobs, &job{fn: jobFunc, interval: interval})
+	s.mu.Lock()
+	s.seq++
+	name := fmt.Sprintf("job-%d", s.seq)
+	s.mu.Unlock()
+
+	_ = s.Add(name, func(context.Context) error {
+		jobFunc()
+		return nil
+	}, Every(interval))
+}
+
+// Add add job run by schedule, job is scheduled immediately when scheduler is started.
+func (s *Sched) Add(name string, jobFunc JobFunc, schedule Schedule, opts ...JobOption) error {
+	j := &job{name: name, fn: jobFunc, schedule: schedule, overlap: config.OverlapSkip}
+	for _, opt := range opts {
+		opt(j)
+	}
+	j.status.Name = name
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		return ErrStopped
+	}
+	if _, ok := s.jobs[name]; ok {
+		return fmt.Errorf("%w: %s", ErrJobExists, name)
+	}
+
+	j.ctx, j.cancel = context.WithCancel(s.ctx)
+	s.jobs[name] = j
+
+	if s.started {
+		s.loops.Add(1)
+		go s.loop(j)
+	}
+
+	return nil
+}
+
+// Remove stop scheduling job and cancel its running run.
+func (s *Sched) Remove(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.jobs[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrJobNotFound, name)
+	}
+
+	j.cancel()
+	delete(s.jobs, name)
+
+	return nil
+}
+
+// Trigger run job now by its overlap policy, returns ErrJobRunning when run is skipped.
+func (s *Sched) Trigger(name string) error {
+	s.mu.Lock()
+	j, ok := s.jobs[name]
+	s.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrJobNotFound, name)
+	}
+
+	return s.trigger(j)
+}
+
+// Cancel cancel running run of job and its queued run, job is still scheduled.
+func (s *Sched) Cancel(name string) error {
+	s.mu.Lock()
+	j, ok := s.jobs[name]
+	s.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrJobNotFound, name)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if !j.status.Running {
+		return fmt.Errorf("%w: %s", ErrNotRunning, name)
+	}
+
+	j.status.Queued = false
+	j.cancelRun()
+
+	return nil
+}
+
+// Job returns status of job.
+func (s *Sched) Job(name string) (JobStatus, error) {
+	s.mu.Lock()
+	j, ok := s.jobs[name]
+	s.mu.Unlock()
+
+	if !ok {
+		return JobStatus{}, fmt.Errorf("%w: %s", ErrJobNotFound, name)
+	}
+
+	return j.snapshot(), nil
+}
+
+// Jobs returns status of all jobs sorted by name.
+func (s *Sched) Jobs() []JobStatus {
+	s.mu.Lock()
+	jobs := make([]*job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+	s.mu.Unlock()
+
+	res := make([]JobStatus, 0, len(jobs))
+	for _, j := range jobs {
+		res = append(res, j.snapshot())
+	}
+	sort.Slice(res, func(i, k int) bool { return res[i].Name < res[k].Name })
+
+	return res
+}
+
+// Handler serve status of jobs as JSON.
+func (s *Sched) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(s.Jobs())
+	})
 }
 
 func (s *Sched) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return len(s.jobs)
 }
 
+// Start schedule jobs until context done, then waits running jobs to finish.
 func (s *Sched) Start() {
-	if len(s.jobs) == 0 {
-		s.log.Warn("sched: no job functions defined, scheduler will not start")
+	s.mu.Lock()
+	if s.started || s.stopped {
+		s.mu.Unlock()
 		return
 	}
+	s.started = true
 
+	if len(s.jobs) == 0 {
+		s.log.Warn("sched: no job functions defined yet")
+	}
 	s.log.Info("starting scheduler jobs...", "total_jobs", len(s.jobs))
 
 	for _, j := range s.jobs {
-		s.wg.Add(1)
-		go func(jFunc func(), interval time.Duration) {
-			defer s.wg.Done()
-			ticker := time.NewTicker(interval)
-
-			defer func() {
-				ticker.Stop()
-				ticker = nil
-			}()
-
-			for {
-				select {
-				case <-s.ctx.Done():
-					return
-				case <-ticker.C:
-					jFunc()
-				}
-			}
-		}(j.fn, j.interval)
-	}
-
-	s.wg.Wait()
+		s.loops.Add(1)
+		go s.loop(j)
+	}
+	s.mu.Unlock()
+
+	<-s.ctx.Done()
+	s.drain()
+}
+
+// Stop cancel all jobs and waits running jobs to finish until ctx done.
+func (s *Sched) Stop(ctx context.Context) error {
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.drain()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Sched) drain() {
+	s.mu.Lock()
+	s.stopped = true
+	s.mu.Unlock()
+
+	s.loops.Wait()
+	s.runs.Wait()
+}
+
+// loop schedule job until it's removed. Next run is scheduled after previous scheduled time,
+// so a job keeps its rate, but runs missed by a long job are skipped.
+func (s *Sched) loop(j *job) {
+	defer s.loops.Done()
+
+	if j.runAtStart {
+		_ = s.trigger(j)
+	}
+
+	scheduled := j.schedule.Next(time.Now())
+
+	for {
+		// zero time is never, cron expression without match or manual job.
+		if scheduled.IsZero() {
+			<-j.ctx.Done()
+			return
+		}
+
+		at := scheduled
+		if j.jitter > 0 {
+			at = at.Add(rand.N(j.jitter))
+		}
+		j.setNext(&at)
+
+		timer := time.NewTimer(time.Until(at))
+
+		select {
+		case <-j.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			metrics.SchedulerLag.Observe(time.Since(at).Seconds())
+			_ = s.trigger(j)
+		}
+
+		j.setNext(nil)
+		now := time.Now()
+		if scheduled = j.schedule.Next(scheduled); scheduled.Before(now) {
+			scheduled = j.schedule.Next(now)
+		}
+	}
+}
+
+// trigger start run of job, or skip or queue it by overlap policy while job is running.
+func (s *Sched) trigger(j *job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped || j.ctx.Err() != nil {
+		return ErrStopped
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.status.Running {
+		if j.overlap == config.OverlapQueue {
+			j.status.Queued = true
+			return nil
+		}
+
+		j.status.Skipped++
+		metrics.SchedulerJobRuns.WithLabelValues(j.name, "skipped").Inc()
+		s.log.Warn("sched: job is still running, run skipped", "job", j.name)
+		return ErrJobRunning
+	}
+
+	j.status.Running = true
+	ctx := j.newRun()
+	s.runs.Add(1)
+	go s.exec(ctx, j)
+
+	return nil
+}
+
+// exec run job and queued runs of it.
+func (s *Sched) exec(ctx context.Context, j *job) {
+	defer s.runs.Done()
+
+	for {
+		start := time.Now()
+		err := j.fn(ctx)
+
+		result := "success"
+		switch {
+		case err != nil && ctx.Err() != nil:
+			result = "canceled"
+			s.log.Debug("sched: job canceled", "job", j.name, "err", err.Error())
+		case err != nil:
+			result = "error"
+			s.log.Error("sched: job failed", "job", j.name, "err", err.Error())
+		}
+		metrics.SchedulerJobRuns.WithLabelValues(j.name, result).Inc()
+
+		j.mu.Lock()
+		j.status.Runs++
+		j.status.LastRun = &start
+		j.status.DurationMS = time.Since(start).Milliseconds()
+		j.status.LastError = ""
+		if err != nil {
+			j.status.Failures++
+			j.status.LastError = err.Error()
+		}
+
+		j.cancelRun()
+
+		if !j.status.Queued || j.ctx.Err() != nil {
+			j.status.Running = false
+			j.status.Queued = false
+			j.mu.Unlock()
+			return
+		}
+
+		j.status.Queued = false
+		ctx = j.newRun()
+		j.mu.Unlock()
+	}
+}
+
+// newRun returns context of a run, j.mu must be held.
+func (j *job) newRun() context.Context {
+	ctx, cancel := context.WithCancel(j.ctx)
+	j.cancelRun = cancel
+	return ctx
+}
+
+func (j *job) setNext(at *time.Time) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.status.NextRun = at
+}
+
+func (j *job) snapshot() JobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.status
 }
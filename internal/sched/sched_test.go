@@ -2,11 +2,18 @@ package sched
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/Ja7ad/meilisitemap/config"
 	"github.com/Ja7ad/meilisitemap/internal/logger"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSched(t *testing.T) {
@@ -83,3 +90,254 @@ func TestSchedMultipleJobs(t *testing.T) {
 	assert.Greater(t, count1, 0, "Expected job1 to have run at least once")
 	assert.Greater(t, count2, 0, "Expected job2 to have run at least once")
 }
+
+func TestCron(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	from := time.Date(2024, 1, 2, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		expr    string
+		loc     *time.Location
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "nightly utc", expr: "0 3 * * *", loc: time.UTC, want: time.Date(2024, 1, 3, 3, 0, 0, 0, time.UTC)},
+		{name: "nightly berlin", expr: "0 3 * * *", loc: berlin, want: time.Date(2024, 1, 3, 2, 0, 0, 0, time.UTC)},
+		{name: "every 15 minutes", expr: "*/15 * * * *", loc: time.UTC, want: time.Date(2024, 1, 2, 10, 45, 0, 0, time.UTC)},
+		{name: "descriptor", expr: "@hourly", loc: time.UTC, want: time.Date(2024, 1, 2, 11, 0, 0, 0, time.UTC)},
+		{name: "invalid", expr: "0 3 * *", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Cron(tt.expr, tt.loc)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidCron)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(s.Next(from)), "next is %s", s.Next(from))
+		})
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	nightly, err := Cron("0 3 * * *", time.UTC)
+	require.NoError(t, err)
+	weekdays, err := Cron("0 3 * * 1-5", time.UTC)
+	require.NoError(t, err)
+
+	friday := time.Date(2024, 1, 5, 3, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		schedule Schedule
+		n        int
+		want     time.Time
+	}{
+		{name: "every", schedule: Every(time.Minute), n: 3, want: friday.Add(3 * time.Minute)},
+		{name: "nightly", schedule: nightly, n: 3, want: time.Date(2024, 1, 8, 3, 0, 0, 0, time.UTC)},
+		{name: "weekdays over weekend", schedule: weekdays, n: 1, want: time.Date(2024, 1, 8, 3, 0, 0, 0, time.UTC)},
+		{name: "weekdays", schedule: weekdays, n: 3, want: time.Date(2024, 1, 10, 3, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Deadline(tt.schedule, friday, tt.n)
+			assert.True(t, tt.want.Equal(got), "deadline is %s", got)
+		})
+	}
+}
+
+func TestSchedOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []JobOption
+		interval time.Duration
+		wait     time.Duration
+		min, max int32
+	}{
+		{name: "wait first interval", interval: 100 * time.Millisecond, wait: 50 * time.Millisecond, min: 0, max: 0},
+		{name: "run at start", opts: []JobOption{WithRunAtStart()}, interval: 100 * time.Millisecond, wait: 50 * time.Millisecond, min: 1, max: 1},
+		{
+			name:     "jitter",
+			opts:     []JobOption{WithJitter(40 * time.Millisecond)},
+			interval: 40 * time.Millisecond,
+			wait:     300 * time.Millisecond,
+			min:      3,
+			max:      7,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), tt.wait)
+			defer cancel()
+
+			var count atomic.Int32
+			s := New(ctx, logger.DefaultLogger)
+			require.NoError(t, s.Add("job", func(context.Context) error {
+				count.Add(1)
+				return nil
+			}, Every(tt.interval), tt.opts...))
+			s.Start()
+
+			assert.GreaterOrEqual(t, count.Load(), tt.min)
+			assert.LessOrEqual(t, count.Load(), tt.max)
+		})
+	}
+}
+
+func TestSchedOverlap(t *testing.T) {
+	tests := []struct {
+		name    string
+		overlap config.Overlap
+		runs    int
+		skipped int
+	}{
+		{name: "skip", overlap: config.OverlapSkip, runs: 1, skipped: 2},
+		{name: "queue", overlap: config.OverlapQueue, runs: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(context.Background(), logger.DefaultLogger)
+
+			release := make(chan struct{})
+			require.NoError(t, s.Add("job", func(context.Context) error {
+				<-release
+				return nil
+			}, Manual, WithOverlap(tt.overlap)))
+
+			require.NoError(t, s.Trigger("job"))
+			for range 2 {
+				err := s.Trigger("job")
+				if tt.overlap == config.OverlapSkip {
+					assert.ErrorIs(t, err, ErrJobRunning)
+				} else {
+					assert.NoError(t, err)
+				}
+			}
+			close(release)
+
+			var st JobStatus
+			require.Eventually(t, func() bool {
+				st, _ = s.Job("job")
+				return !st.Running
+			}, time.Second, 5*time.Millisecond)
+			assert.Equal(t, tt.runs, st.Runs)
+			assert.Equal(t, tt.skipped, st.Skipped)
+			assert.False(t, st.Running)
+		})
+	}
+}
+
+func TestSchedJobs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := New(ctx, logger.DefaultLogger)
+	require.NoError(t, s.Add("failing", func(context.Context) error {
+		return errors.New("boom")
+	}, Every(time.Hour), WithRunAtStart()))
+
+	go s.Start()
+
+	// jobs can be added while scheduler is running.
+	var ran atomic.Bool
+	require.NoError(t, s.Add("added", func(context.Context) error {
+		ran.Store(true)
+		return nil
+	}, Every(10*time.Millisecond)))
+	assert.ErrorIs(t, s.Add("added", nil, Manual), ErrJobExists)
+
+	time.Sleep(50 * time.Millisecond)
+	assert.True(t, ran.Load())
+
+	require.NoError(t, s.Remove("added"))
+	assert.ErrorIs(t, s.Remove("added"), ErrJobNotFound)
+	assert.ErrorIs(t, s.Trigger("added"), ErrJobNotFound)
+
+	jobs := s.Jobs()
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "failing", jobs[0].Name)
+	assert.Equal(t, 1, jobs[0].Runs)
+	assert.Equal(t, 1, jobs[0].Failures)
+	assert.Equal(t, "boom", jobs[0].LastError)
+	require.NotNil(t, jobs[0].LastRun)
+	require.NotNil(t, jobs[0].NextRun)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *jobs[0].NextRun, time.Second)
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/jobs", nil))
+
+	var res []JobStatus
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&res))
+	require.Len(t, res, 1)
+	assert.Equal(t, "failing", res[0].Name)
+	assert.Equal(t, "boom", res[0].LastError)
+}
+
+func TestSchedDrain(t *testing.T) {
+	s := New(context.Background(), logger.DefaultLogger)
+
+	var canceled atomic.Bool
+	require.NoError(t, s.Add("long", func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(20 * time.Millisecond)
+		canceled.Store(true)
+		return ctx.Err()
+	}, Manual))
+	require.NoError(t, s.Trigger("long"))
+
+	require.NoError(t, s.Stop(context.Background()))
+	assert.True(t, canceled.Load(), "stop waits running job")
+	assert.ErrorIs(t, s.Add("late", nil, Manual), ErrStopped)
+
+	s = New(context.Background(), logger.DefaultLogger)
+	require.NoError(t, s.Add("stuck", func(context.Context) error {
+		time.Sleep(200 * time.Millisecond)
+		return nil
+	}, Manual))
+	require.NoError(t, s.Trigger("stuck"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, s.Stop(ctx), context.DeadlineExceeded)
+}
+
+func TestSchedCancel(t *testing.T) {
+	s := New(context.Background(), logger.DefaultLogger)
+
+	var runs atomic.Int32
+	require.NoError(t, s.Add("job", func(ctx context.Context) error {
+		runs.Add(1)
+		<-ctx.Done()
+		return ctx.Err()
+	}, Manual, WithOverlap(config.OverlapQueue)))
+
+	assert.ErrorIs(t, s.Cancel("job"), ErrNotRunning)
+	assert.ErrorIs(t, s.Cancel("missing"), ErrJobNotFound)
+
+	require.NoError(t, s.Trigger("job"))
+	require.NoError(t, s.Trigger("job"))
+	require.NoError(t, s.Cancel("job"))
+
+	var st JobStatus
+	require.Eventually(t, func() bool {
+		st, _ = s.Job("job")
+		return !st.Running
+	}, time.Second, 5*time.Millisecond)
+
+	assert.Equal(t, int32(1), runs.Load(), "queued run is canceled")
+	assert.Equal(t, 1, st.Failures)
+	assert.Equal(t, context.Canceled.Error(), st.LastError)
+
+	// job is still scheduled after cancel.
+	require.NoError(t, s.Trigger("job"))
+	require.NoError(t, s.Stop(context.Background()))
+	assert.Equal(t, int32(2), runs.Load())
+}
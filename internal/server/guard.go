@@ -0,0 +1,98 @@
+package server
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/Ja7ad/meilisitemap/config"
+)
+
+// guard allow requests of private routes from allowed networks with valid credentials.
+type guard struct {
+	allow []netip.Prefix
+	auth  *config.AuthConfig
+}
+
+func newGuard(serve *config.ServeConfig) (*guard, error) {
+	g := &guard{auth: serve.Auth}
+
+	for _, cidr := range serve.AllowCIDRs {
+		p, err := config.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q", config.ErrInvalidCIDR, cidr)
+		}
+		g.allow = append(g.allow, p.Masked())
+	}
+
+	return g, nil
+}
+
+func (g *guard) wrap(next http.Handler) http.Handler {
+	if len(g.allow) == 0 && g.auth == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !g.allowed(r) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		if !g.authorized(r) {
+			if g.auth.Type == config.AuthBasic {
+				w.Header().Set("WWW-Authenticate", `Basic realm="meilisitemap"`)
+			} else {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="meilisitemap"`)
+			}
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowed check remote address of connection, forwarded headers are not trusted.
+func (g *guard) allowed(r *http.Request) bool {
+	if len(g.allow) == 0 {
+		return true
+	}
+
+	addrPort, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	addr := addrPort.Addr().Unmap()
+
+	for _, p := range g.allow {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (g *guard) authorized(r *http.Request) bool {
+	if g.auth == nil {
+		return true
+	}
+
+	switch g.auth.Type {
+	case config.AuthBasic:
+		user, pass, ok := r.BasicAuth()
+		return ok && equal(user, g.auth.Username) && equal(pass, g.auth.Password)
+	case config.AuthBearer:
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		return ok && equal(token, g.auth.Token)
+	default:
+		return false
+	}
+}
+
+func equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+const _readinessTimeout = 5 * time.Second
+
+// Readiness is state of dependencies and sitemaps, returned as body of /readyz.
+type Readiness struct {
+	Ready       bool           `json:"ready"`
+	Meilisearch *Status        `json:"meilisearch,omitempty"`
+	Indexes     []*IndexStatus `json:"indexes,omitempty"`
+}
+
+type Status struct {
+	Ready   bool   `json:"ready"`
+	Message string `json:"message,omitempty"`
+}
+
+type IndexStatus struct {
+	Index       string     `json:"index"`
+	Ready       bool       `json:"ready"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	MaxAge      string     `json:"max_age,omitempty"` // MaxAge of sitemap, empty if sitemap generated only once
+	Message     string     `json:"message,omitempty"`
+}
+
+// ReadinessFunc check readiness of process, it's called on every /readyz request.
+type ReadinessFunc func(ctx context.Context) *Readiness
+
+// SetReadiness set check of /readyz, without it server is ready while it's serving.
+// It must be called before Start.
+func (s *Server) SetReadiness(fn ReadinessFunc) {
+	s.readiness = fn
+}
+
+func (s *Server) healthz(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, &Status{Ready: true})
+}
+
+func (s *Server) readyz(w http.ResponseWriter, r *http.Request) {
+	if s.readiness == nil {
+		writeJSON(w, http.StatusOK, &Readiness{Ready: true})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), _readinessTimeout)
+	defer cancel()
+
+	res := s.readiness(ctx)
+
+	code := http.StatusOK
+	if !res.Ready {
+		code = http.StatusServiceUnavailable
+	}
+
+	writeJSON(w, code, res)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
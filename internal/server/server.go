@@ -2,42 +2,132 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"expvar"
+	"mime"
 	"net/http"
 	"net/http/pprof"
+	"path"
+	"strings"
+	"sync"
 
 	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/Ja7ad/meilisitemap/internal/metrics"
+	"github.com/Ja7ad/meilisitemap/internal/report"
 )
 
+func init() {
+	// browsers apply stylesheets of sitemaps only when served as xsl, feeds are served as xml
+	// so browsers render them by stylesheet instead of downloading.
+	_ = mime.AddExtensionType(".xsl", "text/xsl; charset=utf-8")
+	_ = mime.AddExtensionType(".xslt", "text/xsl; charset=utf-8")
+	_ = mime.AddExtensionType(".rss", "text/xml; charset=utf-8")
+	_ = mime.AddExtensionType(".atom", "text/xml; charset=utf-8")
+}
+
 type Server struct {
-	server *http.Server
-	notify chan error
-	listen string
+	mux       *http.ServeMux // mux of public routes
+	private   *http.ServeMux // private is mux of admin, debug and metrics routes, mux when not separated
+	guard     *guard
+	server    *http.Server
+	admin     *http.Server // admin is server of private routes on admin listen, nil when not separated
+	notify    chan error
+	listen    string
+	scheme    string
+	readiness ReadinessFunc
 }
 
-func New(serve *config.ServeConfig, storePath string) *Server {
+func New(serve *config.ServeConfig, storePath string) (*Server, error) {
 	mux := http.NewServeMux()
 
 	fileServer := http.FileServer(http.Dir(storePath))
-	mux.Handle("/", http.StripPrefix("/", fileServer))
+	mux.Handle("/", metrics.Instrument(storePath, hideInternal(http.StripPrefix("/", fileServer))))
 
-	if serve.PPROF {
-		debuggerHandler(mux)
+	g, err := newGuard(serve)
+	if err != nil {
+		return nil, err
 	}
 
-	return &Server{
+	s := &Server{
+		mux:     mux,
+		private: mux,
+		guard:   g,
 		server: &http.Server{
 			Addr:    serve.Listen,
 			Handler: mux,
 		},
 		listen: serve.Listen,
-		notify: make(chan error, 1),
+		scheme: "http",
+		notify: make(chan error, 2),
+	}
+
+	if serve.AdminListen != "" && serve.AdminListen != serve.Listen {
+		s.private = http.NewServeMux()
+		s.admin = &http.Server{
+			Addr:    serve.AdminListen,
+			Handler: s.private,
+		}
+	}
+
+	if serve.TLS != nil {
+		certs, err := newCertReloader(serve.TLS.CertFile, serve.TLS.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		s.scheme = "https"
+		for _, srv := range s.servers() {
+			srv.TLSConfig = &tls.Config{
+				MinVersion:     tls.VersionTLS12,
+				GetCertificate: certs.GetCertificate,
+			}
+		}
+	}
+
+	if serve.Metrics {
+		s.HandlePrivate("GET /metrics", metrics.Handler())
 	}
+
+	if serve.PPROF {
+		debuggerHandler(s)
+	}
+
+	mux.HandleFunc("GET /healthz", s.healthz)
+	mux.HandleFunc("GET /readyz", s.readyz)
+
+	return s, nil
+}
+
+// Handle register public handler for pattern, must be called before Start.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// HandlePrivate register handler for pattern on admin listen, requests are allowed by allow_cidrs
+// and auth of serve config. It must be called before Start.
+func (s *Server) HandlePrivate(pattern string, handler http.Handler) {
+	s.private.Handle(pattern, s.guard.wrap(handler))
 }
 
 func (s *Server) Start() {
+	var wg sync.WaitGroup
+
+	for _, srv := range s.servers() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if srv.TLSConfig != nil {
+				s.notify <- srv.ListenAndServeTLS("", "")
+				return
+			}
+			s.notify <- srv.ListenAndServe()
+		}()
+	}
+
 	go func() {
-		s.notify <- s.server.ListenAndServe()
+		wg.Wait()
 		close(s.notify)
 	}()
 }
@@ -47,19 +137,56 @@ func (s *Server) Notify() <-chan error {
 }
 
 func (s *Server) Shutdown(ctx context.Context) error {
-	return s.server.Shutdown(ctx)
+	var errs []error
+	for _, srv := range s.servers() {
+		errs = append(errs, srv.Shutdown(ctx))
+	}
+	return errors.Join(errs...)
 }
 
 func (s *Server) Addr() string {
 	return s.listen
 }
 
-func debuggerHandler(mux *http.ServeMux) *http.ServeMux {
-	mux.HandleFunc("/debug/pprof/", pprof.Index)
-	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
-	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
-	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
-	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
-	mux.Handle("/debug/vars", expvar.Handler())
-	return mux
+// URL returns base url of public routes.
+func (s *Server) URL() string {
+	return s.scheme + "://" + s.listen
+}
+
+// AdminAddr returns address of private routes.
+func (s *Server) AdminAddr() string {
+	if s.admin != nil {
+		return s.admin.Addr
+	}
+	return s.listen
+}
+
+func (s *Server) servers() []*http.Server {
+	if s.admin != nil {
+		return []*http.Server{s.server, s.admin}
+	}
+	return []*http.Server{s.server}
+}
+
+func debuggerHandler(s *Server) {
+	s.HandlePrivate("/debug/pprof/", http.HandlerFunc(pprof.Index))
+	s.HandlePrivate("/debug/pprof/cmdline", http.HandlerFunc(pprof.Cmdline))
+	s.HandlePrivate("/debug/pprof/profile", http.HandlerFunc(pprof.Profile))
+	s.HandlePrivate("/debug/pprof/symbol", http.HandlerFunc(pprof.Symbol))
+	s.HandlePrivate("/debug/pprof/trace", http.HandlerFunc(pprof.Trace))
+	s.HandlePrivate("/debug/vars", expvar.Handler())
+}
+
+// hideInternal respond not found for files of store not meant to be public, report of runs,
+// state of stores written by older versions and temporary files of atomic writes.
+func hideInternal(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := path.Clean("/" + r.URL.Path)
+		if name == "/"+report.FileName || name == "/state" || strings.HasPrefix(name, "/state/") ||
+			strings.HasSuffix(name, ".tmp") {
+			http.NotFound(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
 }
@@ -2,12 +2,17 @@ package server
 
 import (
 	"context"
+	"mime"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/Ja7ad/meilisitemap/config"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewServer(t *testing.T) {
@@ -17,7 +22,8 @@ func TestNewServer(t *testing.T) {
 	}
 	storePath := "./testdata"
 
-	server := New(serveConfig, storePath)
+	server, err := New(serveConfig, storePath)
+	require.NoError(t, err)
 
 	assert.NotNil(t, server)
 	assert.Equal(t, serveConfig.Listen, server.server.Addr)
@@ -32,7 +38,8 @@ func TestServerStartAndNotify(t *testing.T) {
 	}
 	storePath := "./testdata"
 
-	server := New(serveConfig, storePath)
+	server, err := New(serveConfig, storePath)
+	require.NoError(t, err)
 	assert.NotNil(t, server)
 
 	server.Start()
@@ -63,7 +70,8 @@ func TestServerWithPPROF(t *testing.T) {
 	}
 	storePath := "./testdata"
 
-	server := New(serveConfig, storePath)
+	server, err := New(serveConfig, storePath)
+	require.NoError(t, err)
 	assert.NotNil(t, server)
 
 	server.Start()
@@ -83,6 +91,210 @@ func TestServerWithPPROF(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	err := server.Shutdown(ctx)
+	err = server.Shutdown(ctx)
+	assert.NoError(t, err)
+}
+
+func TestServerWithMetrics(t *testing.T) {
+	serveConfig := &config.ServeConfig{
+		Listen:  "127.0.0.1:8083",
+		Metrics: true,
+	}
+
+	server, err := New(serveConfig, "./testdata")
+	require.NoError(t, err)
+	assert.NotNil(t, server)
+
+	server.Start()
+
+	time.Sleep(100 * time.Millisecond)
+
+	resp, err := http.Get("http://127.0.0.1:8083/metrics")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = server.Shutdown(ctx)
 	assert.NoError(t, err)
 }
+
+func TestServerHealth(t *testing.T) {
+	server, err := New(&config.ServeConfig{Listen: "127.0.0.1:0"}, "./testdata")
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	server.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	server.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	server.SetReadiness(func(context.Context) *Readiness {
+		return &Readiness{
+			Ready:       false,
+			Meilisearch: &Status{Ready: true},
+			Indexes:     []*IndexStatus{{Index: "movies", Message: "sitemap not generated yet"}},
+		}
+	})
+
+	rec = httptest.NewRecorder()
+	server.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), `"message":"sitemap not generated yet"`)
+}
+
+func TestServerGuard(t *testing.T) {
+	tests := []struct {
+		name   string
+		serve  *config.ServeConfig
+		remote string
+		auth   func(r *http.Request)
+		code   int
+	}{
+		{name: "open", serve: &config.ServeConfig{}, code: http.StatusOK},
+		{
+			name:   "allowed network",
+			serve:  &config.ServeConfig{AllowCIDRs: []string{"10.0.0.0/8", "192.168.1.10"}},
+			remote: "10.1.2.3:4567",
+			code:   http.StatusOK,
+		},
+		{
+			name:   "allowed address",
+			serve:  &config.ServeConfig{AllowCIDRs: []string{"10.0.0.0/8", "192.168.1.10"}},
+			remote: "192.168.1.10:4567",
+			code:   http.StatusOK,
+		},
+		{
+			name:   "not allowed",
+			serve:  &config.ServeConfig{AllowCIDRs: []string{"10.0.0.0/8"}},
+			remote: "192.168.1.10:4567",
+			code:   http.StatusForbidden,
+		},
+		{
+			name:  "basic",
+			serve: &config.ServeConfig{Auth: &config.AuthConfig{Type: config.AuthBasic, Username: "admin", Password: "pass"}},
+			auth:  func(r *http.Request) { r.SetBasicAuth("admin", "pass") },
+			code:  http.StatusOK,
+		},
+		{
+			name:  "basic wrong password",
+			serve: &config.ServeConfig{Auth: &config.AuthConfig{Type: config.AuthBasic, Username: "admin", Password: "pass"}},
+			auth:  func(r *http.Request) { r.SetBasicAuth("admin", "wrong") },
+			code:  http.StatusUnauthorized,
+		},
+		{
+			name:  "bearer",
+			serve: &config.ServeConfig{Auth: &config.AuthConfig{Type: config.AuthBearer, Token: "secret"}},
+			auth:  func(r *http.Request) { r.Header.Set("Authorization", "Bearer secret") },
+			code:  http.StatusOK,
+		},
+		{
+			name:  "bearer missing",
+			serve: &config.ServeConfig{Auth: &config.AuthConfig{Type: config.AuthBearer, Token: "secret"}},
+			code:  http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.serve.Listen = "127.0.0.1:0"
+			tt.serve.Metrics = true
+
+			server, err := New(tt.serve, "./testdata")
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.remote != "" {
+				req.RemoteAddr = tt.remote
+			}
+			if tt.auth != nil {
+				tt.auth(req)
+			}
+
+			rec := httptest.NewRecorder()
+			server.mux.ServeHTTP(rec, req)
+			assert.Equal(t, tt.code, rec.Code)
+
+			// public routes are not guarded.
+			rec = httptest.NewRecorder()
+			server.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			assert.Equal(t, http.StatusOK, rec.Code)
+		})
+	}
+}
+
+func TestServerAdminListen(t *testing.T) {
+	server, err := New(&config.ServeConfig{
+		Listen:      "127.0.0.1:8084",
+		AdminListen: "127.0.0.1:8085",
+		Metrics:     true,
+	}, "./testdata")
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:8085", server.AdminAddr())
+
+	server.Start()
+	time.Sleep(100 * time.Millisecond)
+
+	resp, err := http.Get("http://127.0.0.1:8084/metrics")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = http.Get("http://127.0.0.1:8085/metrics")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	require.NoError(t, server.Shutdown(context.Background()))
+
+	for err := range server.Notify() {
+		assert.ErrorIs(t, err, http.ErrServerClosed)
+	}
+}
+
+func TestServerHidesInternalFiles(t *testing.T) {
+	store := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(store, "state"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(store, "sitemaps"), 0o755))
+	for _, name := range []string{"report.json", "state/movies.json", "sitemaps/movies.xml", "sitemaps/movies.xml.tmp"} {
+		require.NoError(t, os.WriteFile(filepath.Join(store, name), []byte("{}"), 0o644))
+	}
+
+	server, err := New(&config.ServeConfig{Listen: "127.0.0.1:0"}, store)
+	require.NoError(t, err)
+
+	tests := []struct {
+		path string
+		code int
+	}{
+		{path: "/sitemaps/movies.xml", code: http.StatusOK},
+		{path: "/report.json", code: http.StatusNotFound},
+		{path: "/state/", code: http.StatusNotFound},
+		{path: "/state", code: http.StatusNotFound},
+		{path: "/state/movies.json", code: http.StatusNotFound},
+		{path: "/sitemaps/movies.xml.tmp", code: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.URL.Path = tt.path
+			server.mux.ServeHTTP(rec, req)
+			assert.Equal(t, tt.code, rec.Code)
+		})
+	}
+}
+
+func TestServerContentTypes(t *testing.T) {
+	for ext, typ := range map[string]string{
+		".xsl":  "text/xsl; charset=utf-8",
+		".xslt": "text/xsl; charset=utf-8",
+		".rss":  "text/xml; charset=utf-8",
+		".atom": "text/xml; charset=utf-8",
+	} {
+		assert.Equal(t, typ, mime.TypeByExtension(ext), ext)
+	}
+}
@@ -0,0 +1,366 @@
+package sitemap
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/Ja7ad/meilisitemap/internal/tracing"
+	"github.com/Ja7ad/meilisitemap/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	_rssVersion      = "2.0"
+	_atomXmlns       = "http://www.w3.org/2005/Atom"
+	_contentXmlns    = "http://purl.org/rss/1.0/modules/content/"
+	_dcXmlns         = "http://purl.org/dc/elements/1.1/"
+	_jsonFeedVersion = "https://jsonfeed.org/version/1.1"
+
+	_defaultEnclosureType = "application/octet-stream"
+)
+
+// Feed is feed of index encoded as rss, atom or json feed, items are urls of documents sorted
+// newest first.
+type Feed struct {
+	Title       string
+	Description string
+	Link        string
+	Language    string
+	Author      string
+	Icon        string
+	Self        string // Self is link of encoded feed
+	Updated     time.Time
+	Items       []*FeedItem
+}
+
+type FeedItem struct {
+	Link       string
+	Title      string
+	Summary    string
+	Content    string
+	Author     string
+	Categories []string
+	Updated    time.Time
+	Enclosures []*Enclosure
+}
+
+type Enclosure struct {
+	URL  string
+	Type string
+}
+
+// BuildFeed make feed of index documents with urls of sitemap, documents which can't make url
+// are skipped.
+func (s *Sitemap) BuildFeed(ctx context.Context, index string, docs []map[string]any) *Feed {
+	_, span := tracing.Tracer().Start(ctx, "sitemap.BuildFeed",
+		trace.WithAttributes(attribute.String("index", index), attribute.Int("documents", len(docs))))
+	defer span.End()
+
+	idxCfg := s.indexes[index]
+
+	f := &Feed{Title: index, Link: idxCfg.BaseAddress, Items: make([]*FeedItem, 0, len(docs))}
+	if c := idxCfg.Feed; c != nil {
+		f.Title = c.Title
+		f.Description = c.Description
+		f.Link = c.Link
+		f.Language = c.Language
+		f.Author = c.Author
+		f.Icon = c.Icon
+	}
+
+	seen := make(map[string]struct{}, len(docs))
+	for _, doc := range docs {
+		// documents failed to map are logged by BuildURLSet.
+		u, err := baseURL(doc, idxCfg)
+		if err != nil {
+			continue
+		}
+		if _, ok := seen[u.Loc]; ok {
+			continue
+		}
+		seen[u.Loc] = struct{}{}
+
+		f.Items = append(f.Items, feedItem(u, doc, idxCfg.FieldMap))
+	}
+
+	slices.SortStableFunc(f.Items, func(a, b *FeedItem) int {
+		return b.Updated.Compare(a.Updated)
+	})
+
+	if c := idxCfg.Feed; c != nil && c.MaxItems > 0 && len(f.Items) > c.MaxItems {
+		f.Items = f.Items[:c.MaxItems]
+	}
+
+	f.Updated = time.Now()
+	if len(f.Items) != 0 {
+		f.Updated = f.Items[0].Updated
+	}
+
+	span.SetAttributes(attribute.Int("items", len(f.Items)))
+
+	return f
+}
+
+// feedItem of url, fields failed to map are left empty and title of item is url without title.
+func feedItem(u *URL, doc map[string]any, fm *config.FieldMapConfig) *FeedItem {
+	item := &FeedItem{Link: u.Loc, Title: u.Loc}
+
+	if t, err := time.Parse(_datetimeLayout, u.LastMod); err == nil {
+		item.Updated = t
+	}
+
+	if fm.Image != nil {
+		if img, err := imageFieldMapToSitemapImage(fm.Image, doc); err == nil {
+			item.Enclosures = append(item.Enclosures, &Enclosure{URL: img.Loc, Type: enclosureType(img.Loc)})
+		}
+	}
+
+	if fm.Video != nil {
+		if vid, err := videoFieldMapToSitemapVideo(fm.Video, doc); err == nil && vid.ContentLoc != "" {
+			item.Enclosures = append(item.Enclosures, &Enclosure{URL: vid.ContentLoc, Type: enclosureType(vid.ContentLoc)})
+		}
+	}
+
+	cfg := fm.Feed
+	if cfg == nil {
+		return item
+	}
+
+	if cfg.Title != "" {
+		if title, err := getStringValueFromDoc(cfg.Title, doc); err == nil && title != "" {
+			item.Title = title
+		}
+	}
+
+	if cfg.Summary != "" {
+		item.Summary, _ = getStringValueFromDoc(cfg.Summary, doc)
+	}
+
+	if cfg.Content != "" {
+		item.Content, _ = getStringValueFromDoc(cfg.Content, doc)
+	}
+
+	if cfg.Author != "" {
+		item.Author, _ = getStringValueFromDoc(cfg.Author, doc)
+	}
+
+	if cfg.Categories != "" {
+		switch v := utils.PickByNestedKey(doc, cfg.Categories).(type) {
+		case string:
+			item.Categories = []string{v}
+		case []string:
+			item.Categories = v
+		case []any:
+			for _, c := range v {
+				if str, ok := c.(string); ok {
+					item.Categories = append(item.Categories, str)
+				}
+			}
+		}
+	}
+
+	return item
+}
+
+// enclosureType returns media type of file by extension of url path.
+func enclosureType(loc string) string {
+	u, err := url.Parse(loc)
+	if err != nil {
+		return _defaultEnclosureType
+	}
+
+	if typ := mime.TypeByExtension(path.Ext(u.Path)); typ != "" {
+		return typ
+	}
+
+	return _defaultEnclosureType
+}
+
+// EncodeFeed marshal feed by format, rss and atom feeds link stylesheet when it's not empty.
+func EncodeFeed(f *Feed, format config.FeedFormat, stylesheet string) ([]byte, error) {
+	var v any
+
+	switch format {
+	case config.FeedRSS:
+		v = rssFeed(f)
+	case config.FeedAtom:
+		v = atomFeed(f)
+	case config.FeedJSON:
+		return json.MarshalIndent(jsonFeed(f), "", "  ")
+	default:
+		return nil, fmt.Errorf("%w feed format %s", ErrUnsupportedType, format)
+	}
+
+	b, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(Header(stylesheet), b...), nil
+}
+
+func rssFeed(f *Feed) *RSS {
+	ch := &RssChannel{
+		Title:         f.Title,
+		Link:          f.Link,
+		Description:   f.Description,
+		Language:      f.Language,
+		LastBuildDate: f.Updated.Format(time.RFC1123Z),
+		AtomLink:      &AtomLink{Href: f.Self, Rel: "self", Type: "application/rss+xml"},
+		Items:         make([]*RssItem, 0, len(f.Items)),
+	}
+
+	// description of channel is required.
+	if ch.Description == "" {
+		ch.Description = f.Title
+	}
+
+	if f.Icon != "" {
+		ch.Image = &RssImage{URL: f.Icon, Title: f.Title, Link: f.Link}
+	}
+
+	for _, it := range f.Items {
+		item := &RssItem{
+			Title:       it.Title,
+			Link:        it.Link,
+			Description: it.Summary,
+			Creator:     it.Author,
+			Categories:  it.Categories,
+			GUID:        &RssGUID{IsPermaLink: true, Value: it.Link},
+			PubDate:     it.Updated.Format(time.RFC1123Z),
+		}
+
+		if it.Content != "" {
+			item.Content = &CDATA{Value: it.Content}
+		}
+
+		// rss allows a single enclosure of item.
+		if len(it.Enclosures) != 0 {
+			item.Enclosure = &RssEnclosure{URL: it.Enclosures[0].URL, Type: it.Enclosures[0].Type}
+		}
+
+		ch.Items = append(ch.Items, item)
+	}
+
+	return &RSS{
+		Version:      _rssVersion,
+		AtomXmlns:    _atomXmlns,
+		ContentXmlns: _contentXmlns,
+		DCXmlns:      _dcXmlns,
+		Channel:      ch,
+	}
+}
+
+func atomFeed(f *Feed) *AtomFeed {
+	feed := &AtomFeed{
+		Lang:     f.Language,
+		ID:       f.Self,
+		Title:    f.Title,
+		Subtitle: f.Description,
+		Updated:  f.Updated.Format(time.RFC3339),
+		Links: []*AtomLink{
+			{Href: f.Self, Rel: "self", Type: "application/atom+xml"},
+			{Href: f.Link, Rel: "alternate"},
+		},
+		Icon:    f.Icon,
+		Entries: make([]*AtomEntry, 0, len(f.Items)),
+	}
+
+	// author is required by entries without author when feed has no author.
+	author := f.Author
+	if author == "" {
+		author = f.Title
+	}
+	feed.Author = &AtomPerson{Name: author}
+
+	for _, it := range f.Items {
+		entry := &AtomEntry{
+			ID:      it.Link,
+			Title:   it.Title,
+			Updated: it.Updated.Format(time.RFC3339),
+			Links:   []*AtomLink{{Href: it.Link, Rel: "alternate"}},
+		}
+
+		if it.Author != "" {
+			entry.Author = &AtomPerson{Name: it.Author}
+		}
+
+		if it.Summary != "" {
+			entry.Summary = &AtomText{Type: "text", Value: it.Summary}
+		}
+
+		if it.Content != "" {
+			entry.Content = &AtomText{Type: "html", Value: it.Content}
+		}
+
+		for _, c := range it.Categories {
+			entry.Categories = append(entry.Categories, &AtomCategory{Term: c})
+		}
+
+		for _, e := range it.Enclosures {
+			entry.Links = append(entry.Links, &AtomLink{Href: e.URL, Rel: "enclosure", Type: e.Type})
+		}
+
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	return feed
+}
+
+func jsonFeed(f *Feed) *JSONFeed {
+	feed := &JSONFeed{
+		Version:     _jsonFeedVersion,
+		Title:       f.Title,
+		HomePageURL: f.Link,
+		FeedURL:     f.Self,
+		Description: f.Description,
+		Icon:        f.Icon,
+		Language:    f.Language,
+		Items:       make([]*JSONFeedItem, 0, len(f.Items)),
+	}
+
+	if f.Author != "" {
+		feed.Authors = []*JSONFeedAuthor{{Name: f.Author}}
+	}
+
+	for _, it := range f.Items {
+		item := &JSONFeedItem{
+			ID:           it.Link,
+			URL:          it.Link,
+			Title:        it.Title,
+			Summary:      it.Summary,
+			ContentHTML:  it.Content,
+			DateModified: it.Updated.Format(time.RFC3339),
+			Tags:         it.Categories,
+		}
+
+		// one of content_html or content_text is required.
+		if item.ContentHTML == "" {
+			item.ContentText = it.Summary
+			if item.ContentText == "" {
+				item.ContentText = it.Title
+			}
+		}
+
+		if it.Author != "" {
+			item.Authors = []*JSONFeedAuthor{{Name: it.Author}}
+		}
+
+		for _, e := range it.Enclosures {
+			item.Attachments = append(item.Attachments, &JSONFeedAttachment{URL: e.URL, MimeType: e.Type})
+		}
+
+		feed.Items = append(feed.Items, item)
+	}
+
+	return feed
+}
@@ -0,0 +1,147 @@
+package sitemap
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/Ja7ad/meilisitemap/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFeed(t *testing.T) *Feed {
+	t.Helper()
+
+	cfg := &config.SitemapConfig{
+		BaseAddress: "https://foobar.com/movies",
+		Feed: &config.FeedConfig{
+			Title:    "Movies",
+			Link:     "https://foobar.com",
+			Author:   "Foobar",
+			MaxItems: 2,
+		},
+		FieldMap: &config.FieldMapConfig{
+			UniqueField: "id",
+			LastMod:     "updated_at",
+			Image:       &config.ImageConfig{Loc: "poster"},
+			Feed: &config.FeedItemConfig{
+				Title:      "title",
+				Summary:    "overview",
+				Content:    "body",
+				Categories: "genres",
+			},
+		},
+	}
+
+	sm := New("", map[string]*config.SitemapConfig{"movies": cfg}, logger.DefaultLogger)
+
+	f := sm.BuildFeed(context.Background(), "movies", []map[string]any{
+		{"id": "old", "title": "Old", "updated_at": "2024-01-01T10:00:00Z"},
+		{
+			"id":         "anatomy",
+			"title":      "Anatomy of a Fall",
+			"overview":   "A woman is suspected of murder.",
+			"body":       "<p>Courtroom drama</p>",
+			"genres":     []any{"drama", "thriller"},
+			"poster":     "https://foobar.com/posters/anatomy.jpg",
+			"updated_at": "2024-01-03T10:00:00Z",
+		},
+		{"id": "past-lives", "updated_at": "2024-01-02T10:00:00Z"},
+		{"id": "anatomy", "updated_at": "2024-01-04T10:00:00Z"},
+		{"title": "no id"},
+	})
+	f.Self = "https://foobar.com/sitemaps/movies.rss"
+
+	return f
+}
+
+func TestSitemap_BuildFeed(t *testing.T) {
+	f := newTestFeed(t)
+
+	require.Len(t, f.Items, 2)
+	assert.Equal(t, "Movies", f.Title)
+	assert.True(t, f.Updated.Equal(time.Date(2024, 1, 3, 10, 0, 0, 0, time.UTC)))
+
+	assert.Equal(t, &FeedItem{
+		Link:       "https://foobar.com/movies/anatomy",
+		Title:      "Anatomy of a Fall",
+		Summary:    "A woman is suspected of murder.",
+		Content:    "<p>Courtroom drama</p>",
+		Categories: []string{"drama", "thriller"},
+		Updated:    f.Items[0].Updated,
+		Enclosures: []*Enclosure{{URL: "https://foobar.com/posters/anatomy.jpg", Type: "image/jpeg"}},
+	}, f.Items[0])
+
+	// title of item without title is url.
+	assert.Equal(t, "https://foobar.com/movies/past-lives", f.Items[1].Title)
+}
+
+func TestEncodeFeed(t *testing.T) {
+	f := newTestFeed(t)
+
+	tests := []struct {
+		format   config.FeedFormat
+		contains []string
+	}{
+		{
+			format: config.FeedRSS,
+			contains: []string{
+				`<?xml-stylesheet type="text/xsl" href="/style.xsl"?>`,
+				`<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom"`,
+				`<atom:link href="https://foobar.com/sitemaps/movies.rss" rel="self" type="application/rss+xml"></atom:link>`,
+				`<content:encoded><![CDATA[<p>Courtroom drama</p>]]></content:encoded>`,
+				`<category>drama</category>`,
+				`<guid isPermaLink="true">https://foobar.com/movies/anatomy</guid>`,
+				`<pubDate>Wed, 03 Jan 2024 10:00:00 +0000</pubDate>`,
+				`<enclosure url="https://foobar.com/posters/anatomy.jpg" length="0" type="image/jpeg"></enclosure>`,
+			},
+		},
+		{
+			format: config.FeedAtom,
+			contains: []string{
+				`<feed xmlns="http://www.w3.org/2005/Atom">`,
+				`<link href="https://foobar.com/sitemaps/movies.rss" rel="self" type="application/atom+xml"></link>`,
+				`<author>`,
+				`<updated>2024-01-03T10:00:00Z</updated>`,
+				`<content type="html">&lt;p&gt;Courtroom drama&lt;/p&gt;</content>`,
+				`<category term="thriller"></category>`,
+				`<link href="https://foobar.com/posters/anatomy.jpg" rel="enclosure" type="image/jpeg"></link>`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			b, err := EncodeFeed(f, tt.format, "/style.xsl")
+			require.NoError(t, err)
+
+			for _, s := range tt.contains {
+				assert.Contains(t, string(b), s)
+			}
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		b, err := EncodeFeed(f, config.FeedJSON, "/style.xsl")
+		require.NoError(t, err)
+
+		feed := new(JSONFeed)
+		require.NoError(t, json.Unmarshal(b, feed))
+
+		assert.Equal(t, "https://jsonfeed.org/version/1.1", feed.Version)
+		assert.Equal(t, "https://foobar.com/sitemaps/movies.rss", feed.FeedURL)
+		assert.Equal(t, []*JSONFeedAuthor{{Name: "Foobar"}}, feed.Authors)
+		require.Len(t, feed.Items, 2)
+		assert.Equal(t, "<p>Courtroom drama</p>", feed.Items[0].ContentHTML)
+		assert.Equal(t, []string{"drama", "thriller"}, feed.Items[0].Tags)
+		assert.Equal(t, "image/jpeg", feed.Items[0].Attachments[0].MimeType)
+		// content text is required without content.
+		assert.Equal(t, "https://foobar.com/movies/past-lives", feed.Items[1].ContentText)
+	})
+
+	_, err := EncodeFeed(f, "yaml", "")
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+}
@@ -141,18 +141,26 @@ func videoFieldMapToSitemapVideo(vidCfg *config.VideoConfig, doc map[string]inte
 
 	var err error
 
-	if vidCfg.PlayerAutoPlay != "" {
-		val := utils.PickByNestedKey(doc, vidCfg.PlayerAutoPlay)
-		if val == nil {
-			return nil, fmt.Errorf("failed to get value loc for key: %s", vidCfg.PlayerAutoPlay)
-		}
-
-		vid.PlayerLocAutoplay, err = getBoolValueToZeroOrOne(vidCfg.PlayerAutoPlay, val)
+	if vidCfg.PlayerLoc != "" {
+		vid.PlayerLoc = new(VideoPlayerLoc)
+		vid.PlayerLoc.Loc, err = getStringValueFromDoc(vidCfg.PlayerLoc, doc)
 		if err != nil {
 			return nil, err
 		}
 
-		vid.PlayerLocAutoplay = "ap=" + vid.PlayerLocAutoplay
+		if vidCfg.PlayerAutoPlay != "" {
+			val := utils.PickByNestedKey(doc, vidCfg.PlayerAutoPlay)
+			if val == nil {
+				return nil, fmt.Errorf("failed to get value loc for key: %s", vidCfg.PlayerAutoPlay)
+			}
+
+			vid.PlayerLoc.Autoplay, err = getBoolValueToZeroOrOne(vidCfg.PlayerAutoPlay, val)
+			if err != nil {
+				return nil, err
+			}
+
+			vid.PlayerLoc.Autoplay = "ap=" + vid.PlayerLoc.Autoplay
+		}
 	}
 
 	if vidCfg.Title != "" {
@@ -211,13 +219,6 @@ func videoFieldMapToSitemapVideo(vidCfg *config.VideoConfig, doc map[string]inte
 		}
 	}
 
-	if vidCfg.PlayerLoc != "" {
-		vid.PlayerLoc, err = getStringValueFromDoc(vidCfg.PlayerLoc, doc)
-		if err != nil {
-			return nil, err
-		}
-	}
-
 	if vidCfg.PublicationDate != "" {
 		val := utils.PickByNestedKey(doc, vidCfg.PublicationDate)
 		if val == nil {
@@ -237,23 +238,29 @@ func videoFieldMapToSitemapVideo(vidCfg *config.VideoConfig, doc map[string]inte
 	}
 
 	if vidCfg.RequiresSubscription != "" {
-		vid.RequiresSubscription, err = getStringValueFromDoc(vidCfg.RequiresSubscription, doc)
-		if err != nil {
-			return nil, err
+		val := utils.PickByNestedKey(doc, vidCfg.RequiresSubscription)
+		if b, ok := val.(bool); ok {
+			vid.RequiresSubscription, _ = getBoolValueToYesOrNo(vidCfg.RequiresSubscription, b)
+		} else {
+			vid.RequiresSubscription, err = getStringValueFromDoc(vidCfg.RequiresSubscription, doc)
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
 
 	if vidCfg.Restriction != "" {
-		vid.Restriction, err = getStringValueFromDoc(vidCfg.Restriction, doc)
+		vid.Restriction = new(VideoRestriction)
+		vid.Restriction.Countries, err = getStringValueFromDoc(vidCfg.Restriction, doc)
 		if err != nil {
 			return nil, err
 		}
-	}
 
-	if vidCfg.RestrictionRelationship != "" {
-		vid.RestrictionRelationship, err = getStringValueFromDoc(vidCfg.RestrictionRelationship, doc)
-		if err != nil {
-			return nil, err
+		if vidCfg.RestrictionRelationship != "" {
+			vid.Restriction.Relationship, err = getStringValueFromDoc(vidCfg.RestrictionRelationship, doc)
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
 
@@ -391,16 +398,30 @@ func getDateTimeFromDoc(val interface{}) (string, error) {
 		return val.(time.Time).Format(_datetimeLayout), nil
 	case int64:
 		return time.Unix(val.(int64), 0).Format(_datetimeLayout), nil
+	case int:
+		return time.Unix(int64(val.(int)), 0).Format(_datetimeLayout), nil
+	case float64:
+		return time.Unix(int64(val.(float64)), 0).Format(_datetimeLayout), nil
 	default:
 		return "", fmt.Errorf("unsupported datetime format")
 	}
 }
 
 func getArrayFromDoc(val interface{}) (string, error) {
-	vArry, ok := val.([]string)
-	if !ok {
+	switch vArry := val.(type) {
+	case []string:
+		return strings.Join(vArry, ", "), nil
+	case []interface{}:
+		items := make([]string, 0, len(vArry))
+		for _, item := range vArry {
+			str, ok := item.(string)
+			if !ok {
+				return "", fmt.Errorf("unsupported array item type %T", item)
+			}
+			items = append(items, str)
+		}
+		return strings.Join(items, ", "), nil
+	default:
 		return "", fmt.Errorf("unsupported array format")
 	}
-
-	return strings.Join(vArry, ", "), nil
 }
@@ -2,6 +2,7 @@ package sitemap
 
 import (
 	"testing"
+	"time"
 
 	"github.com/Ja7ad/meilisitemap/config"
 	"github.com/stretchr/testify/assert"
@@ -172,3 +173,42 @@ func TestImageFieldMapToSitemapImage(t *testing.T) {
 		})
 	}
 }
+
+func TestGetArrayFromDoc(t *testing.T) {
+	tests := []struct {
+		name      string
+		val       interface{}
+		expected  string
+		expectErr bool
+	}{
+		{name: "string slice", val: []string{"foo", "bar"}, expected: "foo, bar"},
+		{name: "decoded json array", val: []interface{}{"foo", "bar"}, expected: "foo, bar"},
+		{name: "mixed array", val: []interface{}{"foo", 1}, expectErr: true},
+		{name: "not array", val: "foo", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := getArrayFromDoc(tt.val)
+			if tt.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestGetDateTimeFromDoc(t *testing.T) {
+	expected := time.Unix(1704207845, 0).Format(_datetimeLayout)
+
+	for _, val := range []interface{}{int64(1704207845), 1704207845, float64(1704207845)} {
+		result, err := getDateTimeFromDoc(val)
+		require.NoError(t, err)
+		assert.Equal(t, expected, result)
+	}
+
+	_, err := getDateTimeFromDoc("02/01/2024")
+	require.Error(t, err)
+}
@@ -0,0 +1,62 @@
+package sitemap
+
+import (
+	"slices"
+	"time"
+
+	"github.com/Ja7ad/meilisitemap/config"
+)
+
+// skip reasons of news sitemap.
+const (
+	SkipMissingNews = "missing_news"
+	SkipNewsExpired = "news_expired"
+	SkipNewsLimit   = "news_limit"
+)
+
+// newsURLs keep urls of articles published within config.NewsWindow before now, sorted newest
+// first and capped to config.NewsMaxURLs as required by Google News. Dropped urls are counted
+// in stats.
+func newsURLs(urls []*URL, now time.Time, stats *BuildStats) []*URL {
+	type article struct {
+		url       *URL
+		published time.Time
+	}
+
+	articles := make([]article, 0, len(urls))
+	for _, u := range urls {
+		if u.News == nil {
+			stats.Skipped[SkipMissingNews]++
+			continue
+		}
+
+		published, err := time.Parse(_datetimeLayout, u.News.PubDate)
+		if err != nil {
+			stats.Skipped[SkipMissingNews]++
+			continue
+		}
+
+		if now.Sub(published) > config.NewsWindow {
+			stats.Skipped[SkipNewsExpired]++
+			continue
+		}
+
+		articles = append(articles, article{url: u, published: published})
+	}
+
+	slices.SortStableFunc(articles, func(a, b article) int {
+		return b.published.Compare(a.published)
+	})
+
+	if len(articles) > config.NewsMaxURLs {
+		stats.Skipped[SkipNewsLimit] += len(articles) - config.NewsMaxURLs
+		articles = articles[:config.NewsMaxURLs]
+	}
+
+	res := make([]*URL, 0, len(articles))
+	for _, a := range articles {
+		res = append(res, a.url)
+	}
+
+	return res
+}
@@ -0,0 +1,81 @@
+package sitemap
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/Ja7ad/meilisitemap/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSitemap_BuildNewsURLSet(t *testing.T) {
+	cfg := &config.SitemapConfig{
+		BaseAddress: "https://foobar.com/news",
+		NewsSitemap: true,
+		FieldMap: &config.FieldMapConfig{
+			UniqueField: "id",
+			News: &config.NewsConfig{
+				Publication: &config.NewsPublicationConfig{Name: "publisher", Language: "lang"},
+				PubDate:     "published_at",
+				Title:       "title",
+			},
+		},
+	}
+
+	sm := New("", map[string]*config.SitemapConfig{"news": cfg}, logger.DefaultLogger)
+
+	now := time.Now()
+	doc := func(id string, published time.Time) map[string]any {
+		return map[string]any{
+			"id":           id,
+			"title":        id,
+			"publisher":    "The Daily",
+			"lang":         "en",
+			"published_at": published.Format(time.RFC3339),
+		}
+	}
+
+	set, stats := sm.BuildURLSet(context.Background(), "news", []map[string]any{
+		doc("old", now.Add(-49*time.Hour)),
+		doc("yesterday", now.Add(-24*time.Hour)),
+		doc("latest", now.Add(-time.Minute)),
+		doc("morning", now.Add(-6*time.Hour)),
+		{"id": "draft"},
+	})
+
+	locs := make([]string, 0, len(set.URLs))
+	for _, u := range set.URLs {
+		locs = append(locs, u.Loc)
+	}
+
+	assert.Equal(t, []string{
+		"https://foobar.com/news/latest",
+		"https://foobar.com/news/morning",
+		"https://foobar.com/news/yesterday",
+	}, locs)
+	assert.Equal(t, 3, stats.URLs)
+	assert.Equal(t, map[string]int{SkipNewsExpired: 1, SkipMissingNews: 1}, stats.Skipped)
+}
+
+func TestNewsURLsLimit(t *testing.T) {
+	now := time.Now()
+
+	urls := make([]*URL, 0, config.NewsMaxURLs+5)
+	for i := range config.NewsMaxURLs + 5 {
+		urls = append(urls, &URL{
+			Loc:  fmt.Sprintf("https://foobar.com/news/%d", i),
+			News: &News{PubDate: now.Add(-time.Duration(i) * time.Second).Format(_datetimeLayout)},
+		})
+	}
+
+	stats := &BuildStats{Skipped: make(map[string]int)}
+	res := newsURLs(urls, now, stats)
+
+	require.Len(t, res, config.NewsMaxURLs)
+	assert.Equal(t, "https://foobar.com/news/0", res[0].Loc)
+	assert.Equal(t, 5, stats.Skipped[SkipNewsLimit])
+}
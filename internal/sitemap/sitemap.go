@@ -2,8 +2,12 @@ package sitemap
 
 import (
 	"bytes"
+	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"html"
+	"math"
 	"net/url"
 	"regexp"
 	"strconv"
@@ -12,10 +16,13 @@ import (
 
 	"github.com/Ja7ad/meilisitemap/config"
 	"github.com/Ja7ad/meilisitemap/internal/logger"
+	"github.com/Ja7ad/meilisitemap/internal/tracing"
 	"github.com/Ja7ad/meilisitemap/utils"
 	"github.com/klauspost/compress/gzip"
 	"github.com/tdewolff/minify/v2"
 	minXml "github.com/tdewolff/minify/v2/xml"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -27,13 +34,30 @@ const (
 	_datetimeLayout = "2006-01-02T15:04:05-07:00"
 )
 
+// skip reasons of documents, used as keys of BuildStats.Skipped.
+const (
+	SkipMissingUnique   = "missing_unique_field"
+	SkipEmptySlug       = "empty_slug"
+	SkipBadDate         = "bad_date"
+	SkipUnsupportedType = "unsupported_type"
+	SkipInvalidLoc      = "invalid_loc"
+)
+
+var (
+	ErrMissingUnique   = errors.New("missing unique field")
+	ErrEmptySlug       = errors.New("empty slug of unique field")
+	ErrBadDate         = errors.New("bad date")
+	ErrUnsupportedType = errors.New("unsupported type")
+)
+
 type Sitemap struct {
 	indexes    map[string]*config.SitemapConfig
-	stylesheet config.Stylesheet
+	stylesheet string // stylesheet is href of xsl stylesheet, empty without stylesheet
 	log        logger.Logger
 }
 
-func New(stylesheet config.Stylesheet,
+// New returns sitemap builder of sitemaps, stylesheet is href of xsl linked by every sitemap.
+func New(stylesheet string,
 	sitemaps map[string]*config.SitemapConfig, log logger.Logger,
 ) *Sitemap {
 	return &Sitemap{
@@ -43,7 +67,41 @@ func New(stylesheet config.Stylesheet,
 	}
 }
 
-func (s *Sitemap) CreateSitemap(index string, docs []map[string]any) ([]byte, error) {
+// BuildStats is counters of building url set from documents.
+type BuildStats struct {
+	Documents  int            `json:"documents"`
+	URLs       int            `json:"urls"`
+	Skipped    map[string]int `json:"skipped"`    // Skipped documents by reason
+	Duplicates int            `json:"duplicates"` // Duplicates are documents dropped because of existing loc
+}
+
+// TotalSkipped returns number of skipped documents for all reasons.
+func (b *BuildStats) TotalSkipped() int {
+	total := 0
+	for _, n := range b.Skipped {
+		total += n
+	}
+	return total
+}
+
+func (s *Sitemap) CreateSitemap(ctx context.Context, index string, docs []map[string]any) (b []byte, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "sitemap.CreateSitemap",
+		trace.WithAttributes(attribute.String("index", index)))
+	defer func() {
+		tracing.End(span, err)
+	}()
+
+	set, _ := s.BuildURLSet(ctx, index, docs)
+	return s.Encode(ctx, index, set)
+}
+
+// BuildURLSet make url set of index documents, documents which can't make url are skipped
+// and counted by reason in stats.
+func (s *Sitemap) BuildURLSet(ctx context.Context, index string, docs []map[string]any) (*URLSet, *BuildStats) {
+	_, span := tracing.Tracer().Start(ctx, "sitemap.BuildURLSet",
+		trace.WithAttributes(attribute.String("index", index), attribute.Int("documents", len(docs))))
+	defer span.End()
+
 	idxCfg := s.indexes[index]
 
 	sitemap := new(URLSet)
@@ -63,73 +121,159 @@ func (s *Sitemap) CreateSitemap(index string, docs []map[string]any) ([]byte, er
 
 	sitemap.URLs = make([]*URL, 0)
 
+	stats := &BuildStats{
+		Documents: len(docs),
+		Skipped:   make(map[string]int),
+	}
+
 	for _, doc := range docs {
 		u, err := s.urlMaker(doc, idxCfg)
 		if err != nil {
 			s.log.Warn(err.Error())
+			stats.Skipped[skipReason(err)]++
 			continue
 		}
-		if !isExistsLoc(u, sitemap.URLs) {
-			sitemap.URLs = append(sitemap.URLs, u)
+		if isExistsLoc(u, sitemap.URLs) {
+			stats.Duplicates++
+			continue
 		}
+		sitemap.URLs = append(sitemap.URLs, u)
+	}
+
+	if idxCfg.NewsSitemap {
+		sitemap.URLs = newsURLs(sitemap.URLs, time.Now(), stats)
 	}
 
+	stats.URLs = len(sitemap.URLs)
+	span.SetAttributes(attribute.Int("urls", stats.URLs), attribute.Int("skipped", stats.TotalSkipped()))
+
+	return sitemap, stats
+}
+
+// Encode marshal url set with xml header and stylesheet, minify and compress it if enabled for index.
+func (s *Sitemap) Encode(ctx context.Context, index string, sitemap *URLSet) (b []byte, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "sitemap.Encode", trace.WithAttributes(attribute.String("index", index)))
+	defer func() {
+		tracing.End(span, err)
+	}()
+
+	idxCfg := s.indexes[index]
+
 	xmlData, err := marshal(sitemap)
 	if err != nil {
 		return nil, err
 	}
 
-	header := []byte(xmlHeader + "\n")
+	fullXmlData := append(Header(s.stylesheet), xmlData...)
 
-	if s.stylesheet != "" {
-		header = []byte(xmlHeader + fmt.Sprintf(stylesheetLayout, s.stylesheet.Link()) + "\n")
+	b, err = minifyXML(ctx, fullXmlData)
+	if err != nil {
+		return nil, err
 	}
 
-	fullXmlData := append(header, xmlData...)
+	if idxCfg.Compress {
+		return Compress(ctx, b)
+	}
+
+	return b, nil
+}
+
+// Header returns xml header of documents, with processing instruction of stylesheet when
+// href is not empty.
+func Header(stylesheet string) []byte {
+	if stylesheet == "" {
+		return []byte(xmlHeader + "\n")
+	}
+	return []byte(xmlHeader + fmt.Sprintf(stylesheetLayout, html.EscapeString(stylesheet)) + "\n")
+}
+
+func minifyXML(ctx context.Context, data []byte) (b []byte, err error) {
+	_, span := tracing.Tracer().Start(ctx, "sitemap.minify", trace.WithAttributes(attribute.Int("bytes", len(data))))
+	defer func() {
+		tracing.End(span, err)
+	}()
 
 	m := minify.New()
 	m.AddFuncRegexp(regexp.MustCompile("[/+]xml$"), minXml.Minify)
-	b, err := m.Bytes("text/xml", fullXmlData)
-
-	if idxCfg.Compress {
-		return compress(b)
+	b, err = m.Bytes("text/xml", data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to minify sitemap: %w", err)
 	}
 
 	return b, nil
 }
 
 func (s *Sitemap) urlMaker(doc map[string]any, cfg *config.SitemapConfig) (*URL, error) {
+	u, err := baseURL(doc, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	unique := utils.PickByNestedKey(doc, cfg.FieldMap.UniqueField)
+
+	if cfg.FieldMap.Image != nil {
+		u.Image, err = imageFieldMapToSitemapImage(cfg.FieldMap.Image, doc)
+		if err != nil {
+			s.log.Warn("failed to create image sitemap", "unique", unique, "err", err)
+		}
+	}
+
+	if cfg.FieldMap.Video != nil {
+		u.Video, err = videoFieldMapToSitemapVideo(cfg.FieldMap.Video, doc)
+		if err != nil {
+			s.log.Warn("failed to create video sitemap", "unique", unique, "err", err)
+		}
+	}
+
+	if cfg.FieldMap.News != nil {
+		u.News, err = newsFieldMapToSitemapNews(cfg.FieldMap.News, doc)
+		if err != nil {
+			s.log.Warn("failed to create news sitemap", "unique", unique, "err", err)
+		}
+	}
+
+	return u, nil
+}
+
+// baseURL make url of document with loc, lastmod, changefreq and priority, without extensions.
+func baseURL(doc map[string]any, cfg *config.SitemapConfig) (*URL, error) {
 	u := new(URL)
 
 	unique := utils.PickByNestedKey(doc, cfg.FieldMap.UniqueField)
 	if unique == nil {
-		return nil, fmt.Errorf("failed to get value unique field %s", cfg.FieldMap.UniqueField)
+		return nil, fmt.Errorf("%w %s", ErrMissingUnique, cfg.FieldMap.UniqueField)
 	}
 
 	var (
-		loc string
-		err error
+		loc  string
+		slug string
+		err  error
 	)
 
-	switch unique.(type) {
+	switch v := unique.(type) {
 	case string:
-		slug := uniqueToSlug(unique.(string))
+		slug = uniqueToSlug(v)
 		if slug == "" {
-			return nil, fmt.Errorf("failed to get value slug field %s", cfg.FieldMap.UniqueField)
-		}
-		if !strings.HasSuffix(cfg.BaseAddress, "=") {
-			loc, err = url.JoinPath(cfg.BaseAddress, slug)
-		} else {
-			loc = fmt.Sprintf("%s%s", cfg.BaseAddress, slug)
+			return nil, fmt.Errorf("%w %s", ErrEmptySlug, cfg.FieldMap.UniqueField)
 		}
 	case int:
-		if !strings.HasSuffix(cfg.BaseAddress, "=") {
-			loc, err = url.JoinPath(cfg.BaseAddress, strconv.Itoa(unique.(int)))
-		} else {
-			loc = fmt.Sprintf("%s%s", cfg.BaseAddress, strconv.Itoa(unique.(int)))
+		slug = strconv.Itoa(v)
+	case int64:
+		slug = strconv.FormatInt(v, 10)
+	case float64:
+		// numbers of documents decoded from json are float64
+		if v != math.Trunc(v) {
+			return nil, fmt.Errorf("%w of unique field %s, value %v is not integer", ErrUnsupportedType, cfg.FieldMap.UniqueField, v)
 		}
+		slug = strconv.FormatInt(int64(v), 10)
 	default:
-		return nil, fmt.Errorf("not supported unique field %s, type is %T", cfg.FieldMap.UniqueField, unique)
+		return nil, fmt.Errorf("%w of unique field %s, type is %T", ErrUnsupportedType, cfg.FieldMap.UniqueField, unique)
+	}
+
+	if !strings.HasSuffix(cfg.BaseAddress, "=") {
+		loc, err = url.JoinPath(cfg.BaseAddress, slug)
+	} else {
+		loc = fmt.Sprintf("%s%s", cfg.BaseAddress, slug)
 	}
 
 	if err != nil {
@@ -140,46 +284,31 @@ func (s *Sitemap) urlMaker(doc map[string]any, cfg *config.SitemapConfig) (*URL,
 	u.Priority = fmt.Sprintf("%g", cfg.FieldMap.Priority.Rate())
 	u.ChangeFreq = cfg.FieldMap.ChangeFreq
 
-	if datetime, ok := doc[cfg.FieldMap.LastMod]; !ok {
+	if datetime := utils.PickByNestedKey(doc, cfg.FieldMap.LastMod); datetime == nil {
 		u.LastMod = time.Now().Format(_datetimeLayout)
 	} else {
 		lastMod, err := getDateTimeFromDoc(datetime)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%w in lastmod field %s: %w", ErrBadDate, cfg.FieldMap.LastMod, err)
 		}
 		u.LastMod = lastMod
 	}
 
-	if cfg.FieldMap.Image != nil {
-		u.Image, err = imageFieldMapToSitemapImage(cfg.FieldMap.Image, doc)
-		if err != nil {
-			s.log.Warn("failed to create image sitemap", "unique", unique, "err", err)
-		}
-	}
-
-	if cfg.FieldMap.Video != nil {
-		u.Video, err = videoFieldMapToSitemapVideo(cfg.FieldMap.Video, doc)
-		if err != nil {
-			s.log.Warn("failed to create video sitemap", "unique", unique, "err", err)
-		}
-	}
-
-	if cfg.FieldMap.News != nil {
-		u.News, err = newsFieldMapToSitemapNews(cfg.FieldMap.News, doc)
-		if err != nil {
-			s.log.Warn("failed to create news sitemap", "unique", unique, "err", err)
-		}
-	}
-
 	return u, nil
 }
 
-func compress(b []byte) ([]byte, error) {
+// Compress gzip b.
+func Compress(ctx context.Context, b []byte) (res []byte, err error) {
+	_, span := tracing.Tracer().Start(ctx, "sitemap.compress", trace.WithAttributes(attribute.Int("bytes", len(b))))
+	defer func() {
+		tracing.End(span, err)
+	}()
+
 	var buf bytes.Buffer
 
 	gzipWriter := gzip.NewWriter(&buf)
 
-	_, err := gzipWriter.Write(b)
+	_, err = gzipWriter.Write(b)
 	if err != nil {
 		return nil, fmt.Errorf("failed to write to gzip writer: %w", err)
 	}
@@ -200,6 +329,21 @@ func marshal(u *URLSet) ([]byte, error) {
 	return b, nil
 }
 
+func skipReason(err error) string {
+	switch {
+	case errors.Is(err, ErrMissingUnique):
+		return SkipMissingUnique
+	case errors.Is(err, ErrEmptySlug):
+		return SkipEmptySlug
+	case errors.Is(err, ErrBadDate):
+		return SkipBadDate
+	case errors.Is(err, ErrUnsupportedType):
+		return SkipUnsupportedType
+	default:
+		return SkipInvalidLoc
+	}
+}
+
 func isExistsLoc(item *URL, items []*URL) bool {
 	for _, u := range items {
 		if item.Loc == u.Loc {
@@ -1,6 +1,7 @@
 package sitemap
 
 import (
+	"context"
 	"github.com/Ja7ad/meilisitemap/internal/logger"
 	"testing"
 	"time"
@@ -60,18 +61,16 @@ var docsForTest = []map[string]interface{}{
 func TestSitemap_CreateSitemap(t *testing.T) {
 	tests := []struct {
 		name       string
-		baseUrl    string
-		stylesheet config.Stylesheet
+		stylesheet string
 		sitemaps   map[string]*config.SitemapConfig
 	}{
 		{
 			name:       "full test normal",
-			baseUrl:    "https://foobar.com",
-			stylesheet: config.Style1,
+			stylesheet: "https://foobar.com/stylesheets/style1.xsl",
 			sitemaps: map[string]*config.SitemapConfig{
 				"index1": {
-					Sitemap:  true,
-					BasePath: "test",
+					Sitemap:     true,
+					BaseAddress: "https://foobar.com/test",
 					FieldMap: &config.FieldMapConfig{
 						UniqueField: "id",
 						LastMod:     "created_at",
@@ -120,10 +119,140 @@ func TestSitemap_CreateSitemap(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			sm := New(test.baseUrl, test.stylesheet, test.sitemaps, logger.DefaultLogger)
-			res, err := sm.CreateSitemap("index1", docsForTest)
+			sm := New(test.stylesheet, test.sitemaps, logger.DefaultLogger)
+			res, err := sm.CreateSitemap(context.Background(), "index1", docsForTest)
 			require.NoError(t, err)
 			require.NotNil(t, res)
 		})
 	}
 }
+
+func TestBaseURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		baseAddress string
+		doc         map[string]interface{}
+		loc         string
+		lastMod     string
+		err         error
+	}{
+		{
+			name:        "string slug",
+			baseAddress: "https://foobar.com/movies",
+			doc:         map[string]interface{}{"id": "Anatomy of a Fall", "meta": map[string]interface{}{"created_at": "2024-01-02T15:04:05Z"}},
+			loc:         "https://foobar.com/movies/anatomy-of-a-fall",
+			lastMod:     "2024-01-02T15:04:05+00:00",
+		},
+		{
+			name:        "int64 unique",
+			baseAddress: "https://foobar.com/movies",
+			doc:         map[string]interface{}{"id": int64(42), "meta": map[string]interface{}{"created_at": int64(1704207845)}},
+			loc:         "https://foobar.com/movies/42",
+			lastMod:     time.Unix(1704207845, 0).Format(_datetimeLayout),
+		},
+		{
+			name:        "json number unique",
+			baseAddress: "https://foobar.com/movies",
+			doc:         map[string]interface{}{"id": float64(42), "meta": map[string]interface{}{"created_at": float64(1704207845)}},
+			loc:         "https://foobar.com/movies/42",
+			lastMod:     time.Unix(1704207845, 0).Format(_datetimeLayout),
+		},
+		{
+			name:        "query base address",
+			baseAddress: "https://foobar.com/movies?id=",
+			doc:         map[string]interface{}{"id": float64(42), "meta": map[string]interface{}{"created_at": "2024-01-02T15:04:05Z"}},
+			loc:         "https://foobar.com/movies?id=42",
+			lastMod:     "2024-01-02T15:04:05+00:00",
+		},
+		{
+			name:        "fractional unique",
+			baseAddress: "https://foobar.com/movies",
+			doc:         map[string]interface{}{"id": 4.2},
+			err:         ErrUnsupportedType,
+		},
+		{
+			name:        "bool unique",
+			baseAddress: "https://foobar.com/movies",
+			doc:         map[string]interface{}{"id": true},
+			err:         ErrUnsupportedType,
+		},
+		{
+			name:        "bad nested lastmod",
+			baseAddress: "https://foobar.com/movies",
+			doc:         map[string]interface{}{"id": "foo", "meta": map[string]interface{}{"created_at": "02/01/2024"}},
+			err:         ErrBadDate,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.SitemapConfig{
+				BaseAddress: tt.baseAddress,
+				FieldMap: &config.FieldMapConfig{
+					UniqueField: "id",
+					LastMod:     "meta.created_at",
+					ChangeFreq:  config.Daily,
+					Priority:    config.High,
+				},
+			}
+
+			u, err := baseURL(tt.doc, cfg)
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.loc, u.Loc)
+			require.Equal(t, tt.lastMod, u.LastMod)
+		})
+	}
+}
+
+func TestNewsFieldMapToSitemapNews_Keywords(t *testing.T) {
+	cfg := &config.NewsConfig{Keywords: "tags"}
+
+	// arrays of documents decoded from json are []interface{}
+	news, err := newsFieldMapToSitemapNews(cfg, map[string]interface{}{"tags": []interface{}{"foo", "bar"}})
+	require.NoError(t, err)
+	require.Equal(t, "foo, bar", news.Keywords)
+
+	_, err = newsFieldMapToSitemapNews(cfg, map[string]interface{}{"tags": []interface{}{"foo", 1}})
+	require.Error(t, err)
+}
+
+func TestSitemap_BuildURLSetStats(t *testing.T) {
+	cfg := &config.SitemapConfig{
+		BaseAddress: "https://foobar.com/movies",
+		FieldMap: &config.FieldMapConfig{
+			UniqueField: "id",
+			LastMod:     "created_at",
+			ChangeFreq:  config.Daily,
+			Priority:    config.High,
+		},
+	}
+
+	sm := New("", map[string]*config.SitemapConfig{"movies": cfg}, logger.DefaultLogger)
+
+	set, stats := sm.BuildURLSet(context.Background(), "movies", []map[string]any{
+		{"id": "anatomy", "created_at": "2024-01-02T15:04:05Z"},
+		{"id": "anatomy", "created_at": "2024-01-03T15:04:05Z"},
+		{"id": "past-lives", "created_at": "yesterday"},
+		{"id": "   "},
+		{"id": true},
+		{"title": "no id"},
+	})
+
+	require.Len(t, set.URLs, 1)
+	require.Equal(t, &BuildStats{
+		Documents:  6,
+		URLs:       1,
+		Duplicates: 1,
+		Skipped: map[string]int{
+			SkipBadDate:         1,
+			SkipEmptySlug:       1,
+			SkipUnsupportedType: 1,
+			SkipMissingUnique:   1,
+		},
+	}, stats)
+	require.Equal(t, 4, stats.TotalSkipped())
+}
@@ -42,22 +42,30 @@ type URL struct {
 }
 
 type Video struct {
-	ThumbnailLoc            string `xml:"video:thumbnail_loc"`
-	Title                   string `xml:"video:title"`
-	Description             string `xml:"video:description"`
-	ContentLoc              string `xml:"video:content_loc"`
-	PlayerLoc               string `xml:"video:player_loc,omitempty"`
-	PlayerLocAutoplay       string `xml:"autoplay,attr,omitempty"`
-	Duration                string `xml:"video:duration,omitempty"`
-	ExpirationDate          string `xml:"video:expiration_date,omitempty"`
-	Rating                  string `xml:"video:rating,omitempty"`
-	ViewCount               string `xml:"video:view_count,omitempty"`
-	PublicationDate         string `xml:"video:publication_date,omitempty"`
-	FamilyFriendly          string `xml:"video:family_friendly,omitempty"`
-	Restriction             string `xml:"video:restriction,omitempty"`
-	RestrictionRelationship string `xml:"relationship,attr,omitempty"`
-	RequiresSubscription    string `xml:"video:requires_subscription,omitempty"`
-	Live                    string `xml:"video:live,omitempty"`
+	ThumbnailLoc         string            `xml:"video:thumbnail_loc"`
+	Title                string            `xml:"video:title"`
+	Description          string            `xml:"video:description"`
+	ContentLoc           string            `xml:"video:content_loc,omitempty"`
+	PlayerLoc            *VideoPlayerLoc   `xml:"video:player_loc,omitempty"`
+	Duration             string            `xml:"video:duration,omitempty"`
+	ExpirationDate       string            `xml:"video:expiration_date,omitempty"`
+	Rating               string            `xml:"video:rating,omitempty"`
+	ViewCount            string            `xml:"video:view_count,omitempty"`
+	PublicationDate      string            `xml:"video:publication_date,omitempty"`
+	FamilyFriendly       string            `xml:"video:family_friendly,omitempty"`
+	Restriction          *VideoRestriction `xml:"video:restriction,omitempty"`
+	RequiresSubscription string            `xml:"video:requires_subscription,omitempty"`
+	Live                 string            `xml:"video:live,omitempty"`
+}
+
+type VideoPlayerLoc struct {
+	Autoplay string `xml:"autoplay,attr,omitempty"`
+	Loc      string `xml:",chardata"`
+}
+
+type VideoRestriction struct {
+	Relationship string `xml:"relationship,attr,omitempty"`
+	Countries    string `xml:",chardata"`
 }
 
 type Image struct {
@@ -82,19 +90,23 @@ type NewsPublication struct {
 }
 
 type RSS struct {
-	XMLName xml.Name    `xml:"rss"`
-	Xmlns   string      `xml:"xmlns:media,attr"`
-	Version string      `xml:"version,attr"`
-	Channel *RssChannel `xml:"channel"`
+	XMLName      xml.Name    `xml:"rss"`
+	Version      string      `xml:"version,attr"`
+	AtomXmlns    string      `xml:"xmlns:atom,attr"`
+	ContentXmlns string      `xml:"xmlns:content,attr"`
+	DCXmlns      string      `xml:"xmlns:dc,attr"`
+	Channel      *RssChannel `xml:"channel"`
 }
 
 type RssChannel struct {
-	Title       string     `xml:"title"`
-	Link        string     `xml:"link"`
-	Description string     `xml:"description"`
-	Language    string     `xml:"language"`
-	Image       *RssImage  `xml:"image"`
-	Items       []*RssItem `xml:"item"`
+	Title         string     `xml:"title"`
+	Link          string     `xml:"link"`
+	Description   string     `xml:"description"`
+	Language      string     `xml:"language,omitempty"`
+	LastBuildDate string     `xml:"lastBuildDate"`
+	AtomLink      *AtomLink  `xml:"atom:link"`
+	Image         *RssImage  `xml:"image,omitempty"`
+	Items         []*RssItem `xml:"item"`
 }
 
 type RssImage struct {
@@ -106,13 +118,105 @@ type RssImage struct {
 type RssItem struct {
 	Title       string        `xml:"title"`
 	Link        string        `xml:"link"`
-	Description string        `xml:"description"`
+	Description string        `xml:"description,omitempty"`
+	Content     *CDATA        `xml:"content:encoded,omitempty"`
+	Creator     string        `xml:"dc:creator,omitempty"`
+	Categories  []string      `xml:"category"`
+	GUID        *RssGUID      `xml:"guid"`
+	PubDate     string        `xml:"pubDate"`
 	Enclosure   *RssEnclosure `xml:"enclosure"`
-	Category    string        `xml:"category"`
+}
+
+type RssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
 }
 
 type RssEnclosure struct {
 	URL    string `xml:"url,attr"`
-	Length string `xml:"length,attr"`
+	Length int64  `xml:"length,attr"`
 	Type   string `xml:"type,attr"`
 }
+
+type CDATA struct {
+	Value string `xml:",cdata"`
+}
+
+type AtomFeed struct {
+	XMLName  xml.Name     `xml:"http://www.w3.org/2005/Atom feed"`
+	Lang     string       `xml:"xml:lang,attr,omitempty"`
+	ID       string       `xml:"id"`
+	Title    string       `xml:"title"`
+	Subtitle string       `xml:"subtitle,omitempty"`
+	Updated  string       `xml:"updated"`
+	Links    []*AtomLink  `xml:"link"`
+	Author   *AtomPerson  `xml:"author,omitempty"`
+	Icon     string       `xml:"icon,omitempty"`
+	Entries  []*AtomEntry `xml:"entry"`
+}
+
+type AtomEntry struct {
+	ID         string          `xml:"id"`
+	Title      string          `xml:"title"`
+	Updated    string          `xml:"updated"`
+	Links      []*AtomLink     `xml:"link"`
+	Author     *AtomPerson     `xml:"author,omitempty"`
+	Summary    *AtomText       `xml:"summary,omitempty"`
+	Content    *AtomText       `xml:"content,omitempty"`
+	Categories []*AtomCategory `xml:"category"`
+}
+
+type AtomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Length int64  `xml:"length,attr,omitempty"`
+}
+
+type AtomPerson struct {
+	Name string `xml:"name"`
+}
+
+type AtomText struct {
+	Type  string `xml:"type,attr,omitempty"`
+	Value string `xml:",chardata"`
+}
+
+type AtomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type JSONFeed struct {
+	Version     string            `json:"version"`
+	Title       string            `json:"title"`
+	HomePageURL string            `json:"home_page_url,omitempty"`
+	FeedURL     string            `json:"feed_url,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Icon        string            `json:"icon,omitempty"`
+	Language    string            `json:"language,omitempty"`
+	Authors     []*JSONFeedAuthor `json:"authors,omitempty"`
+	Items       []*JSONFeedItem   `json:"items"`
+}
+
+type JSONFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+type JSONFeedItem struct {
+	ID           string                `json:"id"`
+	URL          string                `json:"url"`
+	Title        string                `json:"title,omitempty"`
+	Summary      string                `json:"summary,omitempty"`
+	ContentHTML  string                `json:"content_html,omitempty"`
+	ContentText  string                `json:"content_text,omitempty"`
+	DateModified string                `json:"date_modified"`
+	Authors      []*JSONFeedAuthor     `json:"authors,omitempty"`
+	Tags         []string              `json:"tags,omitempty"`
+	Attachments  []*JSONFeedAttachment `json:"attachments,omitempty"`
+}
+
+type JSONFeedAttachment struct {
+	URL         string `json:"url"`
+	MimeType    string `json:"mime_type"`
+	SizeInBytes int64  `json:"size_in_bytes,omitempty"`
+}
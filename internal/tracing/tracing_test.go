@@ -0,0 +1,68 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSetupDisabled(t *testing.T) {
+	for _, cfg := range []*config.TracingConfig{nil, {Enabled: false}} {
+		shutdown, err := Setup(context.Background(), cfg)
+		require.NoError(t, err)
+		assert.NoError(t, shutdown(context.Background()))
+	}
+}
+
+func TestEnd(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	_, span := tp.Tracer("test").Start(context.Background(), "ok")
+	End(span, nil)
+
+	_, span = tp.Tracer("test").Start(context.Background(), "failed")
+	End(span, errors.New("index not found"))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, codes.Unset, spans[0].Status.Code)
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+	assert.Equal(t, "index not found", spans[1].Status.Description)
+}
+
+func TestTransport(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var header string
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("traceparent")
+	}))
+	defer srv.Close()
+
+	tp := sdktrace.NewTracerProvider()
+	ctx, span := tp.Tracer("test").Start(context.Background(), "fetch")
+	defer span.End()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	client := &http.Client{Transport: Transport(nil)}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	assert.Contains(t, header, span.SpanContext().TraceID().String())
+	assert.Empty(t, req.Header.Get("traceparent"))
+}
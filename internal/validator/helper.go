@@ -0,0 +1,83 @@
+package validator
+
+import (
+	"net/url"
+	"strings"
+	"time"
+)
+
+// w3cLayouts are complete forms of W3C datetime https://www.w3.org/TR/NOTE-datetime
+var w3cLayouts = []string{
+	"2006",
+	"2006-01",
+	"2006-01-02",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02T15:04:05.999999999Z07:00",
+}
+
+func isW3CDatetime(val string) bool {
+	for _, layout := range w3cLayouts {
+		if _, err := time.Parse(layout, val); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func absoluteURL(raw string) (*url.URL, bool) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, false
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, false
+	}
+
+	return u, true
+}
+
+// isCountryCode check format of ISO 3166-1 alpha-2 code.
+func isCountryCode(code string) bool {
+	return len(code) == 2 && isUpperLetters(code)
+}
+
+// isLanguageCode check format of ISO 639 code, zh-cn and zh-tw allowed for chinese.
+func isLanguageCode(code string) bool {
+	switch strings.ToLower(code) {
+	case "zh-cn", "zh-tw":
+		return true
+	}
+
+	return (len(code) == 2 || len(code) == 3) && isLowerLetters(code)
+}
+
+func isUpperLetters(s string) bool {
+	for _, c := range s {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func isLowerLetters(s string) bool {
+	for _, c := range s {
+		if c < 'a' || c > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+func isBlank(val *string) bool {
+	return val == nil || strings.TrimSpace(*val) == ""
+}
+
+func deref(val *string) string {
+	if val == nil {
+		return ""
+	}
+	return *val
+}
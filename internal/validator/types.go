@@ -0,0 +1,123 @@
+package validator
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+const _standardXmlns = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+type Kind string
+
+const (
+	KindURLSet       Kind = "urlset"
+	KindSitemapIndex Kind = "sitemapindex"
+)
+
+// Options of validation, zero value use protocol limits and skip host check.
+type Options struct {
+	Host     string // Host every loc must be on, empty skip check
+	MaxURLs  int    // MaxURLs per file, default 50,000
+	MaxBytes int    // MaxBytes of uncompressed file, default 50MiB
+	MaxNews  int    // MaxNews urls with news per file, default 1,000
+}
+
+// Problem is a single protocol violation, Element addressed like "url[3].video.rating".
+type Problem struct {
+	Element string `json:"element"`
+	Loc     string `json:"loc,omitempty"`
+	Message string `json:"message"`
+}
+
+type Result struct {
+	Kind     Kind       `json:"kind"`
+	Entries  int        `json:"entries"`
+	Bytes    int        `json:"bytes"`
+	Problems []*Problem `json:"problems"`
+}
+
+func (p *Problem) String() string {
+	if p.Loc != "" {
+		return fmt.Sprintf("%s (%s): %s", p.Element, p.Loc, p.Message)
+	}
+	return p.Element + ": " + p.Message
+}
+
+// Err returns error if result contains any problem.
+func (r *Result) Err() error {
+	if len(r.Problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %d problem(s), first %s", ErrNotConform, len(r.Problems), r.Problems[0])
+}
+
+func (r *Result) add(element, loc, format string, args ...any) {
+	r.Problems = append(r.Problems, &Problem{
+		Element: element,
+		Loc:     loc,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+type urlSet struct {
+	XMLName xml.Name    `xml:"urlset"`
+	URLs    []*urlEntry `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 url"`
+}
+
+type urlEntry struct {
+	Loc        *string  `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 loc"`
+	LastMod    *string  `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 lastmod"`
+	ChangeFreq *string  `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 changefreq"`
+	Priority   *string  `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 priority"`
+	Images     []*image `xml:"http://www.google.com/schemas/sitemap-image/1.1 image"`
+	Videos     []*video `xml:"http://www.google.com/schemas/sitemap-video/1.1 video"`
+	News       []*news  `xml:"http://www.google.com/schemas/sitemap-news/0.9 news"`
+}
+
+type image struct {
+	Loc *string `xml:"http://www.google.com/schemas/sitemap-image/1.1 loc"`
+}
+
+type video struct {
+	ThumbnailLoc         *string      `xml:"http://www.google.com/schemas/sitemap-video/1.1 thumbnail_loc"`
+	Title                *string      `xml:"http://www.google.com/schemas/sitemap-video/1.1 title"`
+	Description          *string      `xml:"http://www.google.com/schemas/sitemap-video/1.1 description"`
+	ContentLoc           *string      `xml:"http://www.google.com/schemas/sitemap-video/1.1 content_loc"`
+	PlayerLoc            *string      `xml:"http://www.google.com/schemas/sitemap-video/1.1 player_loc"`
+	Duration             *string      `xml:"http://www.google.com/schemas/sitemap-video/1.1 duration"`
+	ExpirationDate       *string      `xml:"http://www.google.com/schemas/sitemap-video/1.1 expiration_date"`
+	Rating               *string      `xml:"http://www.google.com/schemas/sitemap-video/1.1 rating"`
+	ViewCount            *string      `xml:"http://www.google.com/schemas/sitemap-video/1.1 view_count"`
+	PublicationDate      *string      `xml:"http://www.google.com/schemas/sitemap-video/1.1 publication_date"`
+	FamilyFriendly       *string      `xml:"http://www.google.com/schemas/sitemap-video/1.1 family_friendly"`
+	Restriction          *restriction `xml:"http://www.google.com/schemas/sitemap-video/1.1 restriction"`
+	RequiresSubscription *string      `xml:"http://www.google.com/schemas/sitemap-video/1.1 requires_subscription"`
+	Live                 *string      `xml:"http://www.google.com/schemas/sitemap-video/1.1 live"`
+}
+
+type restriction struct {
+	Relationship *string `xml:"relationship,attr"`
+	Countries    string  `xml:",chardata"`
+}
+
+type news struct {
+	Publication     *publication `xml:"http://www.google.com/schemas/sitemap-news/0.9 publication"`
+	PublicationDate *string      `xml:"http://www.google.com/schemas/sitemap-news/0.9 publication_date"`
+	Title           *string      `xml:"http://www.google.com/schemas/sitemap-news/0.9 title"`
+	Keywords        *string      `xml:"http://www.google.com/schemas/sitemap-news/0.9 keywords"`
+}
+
+type publication struct {
+	Name     *string `xml:"http://www.google.com/schemas/sitemap-news/0.9 name"`
+	Language *string `xml:"http://www.google.com/schemas/sitemap-news/0.9 language"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name `xml:"sitemapindex"`
+	Sitemaps []*smLoc `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 sitemap"`
+}
+
+type smLoc struct {
+	Loc     *string `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 loc"`
+	LastMod *string `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 lastmod"`
+}
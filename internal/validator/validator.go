@@ -0,0 +1,358 @@
+// Package validator checks generated sitemaps against sitemap 0.9 protocol
+// and Google image, video and news extensions.
+package validator
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/gzip"
+)
+
+const (
+	_maxURLs     = 50_000
+	_maxBytes    = 50 * 1024 * 1024
+	_maxNews     = 1_000
+	_maxImages   = 1_000
+	_maxLocLen   = 2_048
+	_maxDescLen  = 2_048
+	_maxDuration = 28_800
+)
+
+var (
+	ErrNotConform  = errors.New("sitemap does not conform to protocol")
+	ErrUnknownRoot = errors.New("root element is not urlset or sitemapindex")
+)
+
+// ValidateFile validate sitemap file, gzip compressed files are detected by content.
+func ValidateFile(path string, opts Options) (*Result, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Validate(b, opts)
+}
+
+// Validate validate url set or sitemap index, gzip compressed data is detected by content.
+func Validate(data []byte, opts Options) (*Result, error) {
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gzip: %w", err)
+		}
+		defer func() {
+			_ = r.Close()
+		}()
+
+		data, err = io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gzip: %w", err)
+		}
+	}
+
+	if opts.MaxURLs == 0 {
+		opts.MaxURLs = _maxURLs
+	}
+
+	if opts.MaxBytes == 0 {
+		opts.MaxBytes = _maxBytes
+	}
+
+	if opts.MaxNews == 0 {
+		opts.MaxNews = _maxNews
+	}
+
+	root, err := rootElement(data)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{Bytes: len(data), Problems: make([]*Problem, 0)}
+
+	if res.Bytes > opts.MaxBytes {
+		res.add(root.Local, "", "file size %d bytes exceeds limit of %d bytes", res.Bytes, opts.MaxBytes)
+	}
+
+	if root.Space != _standardXmlns {
+		res.add(root.Local, "", "namespace must be %s, got %q", _standardXmlns, root.Space)
+	}
+
+	switch root.Local {
+	case string(KindURLSet):
+		res.Kind = KindURLSet
+		set := new(urlSet)
+		if err := xml.Unmarshal(data, set); err != nil {
+			return nil, fmt.Errorf("failed to parse urlset: %w", err)
+		}
+		validateURLSet(res, set, opts)
+	case string(KindSitemapIndex):
+		res.Kind = KindSitemapIndex
+		idx := new(sitemapIndex)
+		if err := xml.Unmarshal(data, idx); err != nil {
+			return nil, fmt.Errorf("failed to parse sitemapindex: %w", err)
+		}
+		validateSitemapIndex(res, idx, opts)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownRoot, root.Local)
+	}
+
+	return res, nil
+}
+
+func rootElement(data []byte) (xml.Name, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return xml.Name{}, fmt.Errorf("failed to parse xml: %w", err)
+		}
+
+		if se, ok := tok.(xml.StartElement); ok {
+			return se.Name, nil
+		}
+	}
+}
+
+func validateURLSet(res *Result, set *urlSet, opts Options) {
+	res.Entries = len(set.URLs)
+
+	if res.Entries > opts.MaxURLs {
+		res.add("urlset", "", "contains %d urls, limit is %d", res.Entries, opts.MaxURLs)
+	}
+
+	seen := make(map[string]struct{}, len(set.URLs))
+	newsCount := 0
+
+	for i, u := range set.URLs {
+		el := fmt.Sprintf("url[%d]", i)
+		loc := deref(u.Loc)
+
+		if u.Loc == nil {
+			res.add(el, "", "loc is required")
+		} else {
+			checkLoc(res, el+".loc", loc, loc, opts.Host)
+
+			if _, ok := seen[loc]; ok {
+				res.add(el+".loc", loc, "duplicate loc")
+			}
+			seen[loc] = struct{}{}
+		}
+
+		if u.LastMod != nil && !isW3CDatetime(*u.LastMod) {
+			res.add(el+".lastmod", loc, "invalid W3C datetime %q", *u.LastMod)
+		}
+
+		if u.ChangeFreq != nil {
+			switch *u.ChangeFreq {
+			case "always", "hourly", "daily", "weekly", "monthly", "yearly", "never":
+			default:
+				res.add(el+".changefreq", loc, "invalid value %q", *u.ChangeFreq)
+			}
+		}
+
+		if u.Priority != nil {
+			p, err := strconv.ParseFloat(*u.Priority, 64)
+			if err != nil || p < 0 || p > 1 {
+				res.add(el+".priority", loc, "must be a number between 0.0 and 1.0, got %q", *u.Priority)
+			}
+		}
+
+		if len(u.Images) > _maxImages {
+			res.add(el, loc, "contains %d images, limit is %d", len(u.Images), _maxImages)
+		}
+
+		for j, img := range u.Images {
+			imgEl := fmt.Sprintf("%s.image[%d]", el, j)
+			if img.Loc == nil {
+				res.add(imgEl, loc, "loc is required")
+				continue
+			}
+			checkLoc(res, imgEl+".loc", loc, *img.Loc, "")
+		}
+
+		for j, vid := range u.Videos {
+			validateVideo(res, fmt.Sprintf("%s.video[%d]", el, j), loc, vid)
+		}
+
+		if len(u.News) != 0 {
+			newsCount++
+		}
+
+		if len(u.News) > 1 {
+			res.add(el, loc, "contains %d news, only one allowed", len(u.News))
+		}
+
+		for j, n := range u.News {
+			validateNews(res, fmt.Sprintf("%s.news[%d]", el, j), loc, n)
+		}
+	}
+
+	if newsCount > opts.MaxNews {
+		res.add("urlset", "", "contains %d news urls, limit is %d", newsCount, opts.MaxNews)
+	}
+}
+
+func validateVideo(res *Result, el, loc string, vid *video) {
+	if vid.ThumbnailLoc == nil {
+		res.add(el, loc, "thumbnail_loc is required")
+	} else {
+		checkLoc(res, el+".thumbnail_loc", loc, *vid.ThumbnailLoc, "")
+	}
+
+	if isBlank(vid.Title) {
+		res.add(el, loc, "title is required")
+	}
+
+	if isBlank(vid.Description) {
+		res.add(el, loc, "description is required")
+	} else if n := len([]rune(*vid.Description)); n > _maxDescLen {
+		res.add(el+".description", loc, "length %d exceeds limit of %d characters", n, _maxDescLen)
+	}
+
+	if vid.ContentLoc == nil && vid.PlayerLoc == nil {
+		res.add(el, loc, "one of content_loc or player_loc is required")
+	}
+
+	if vid.ContentLoc != nil {
+		checkLoc(res, el+".content_loc", loc, *vid.ContentLoc, "")
+		if *vid.ContentLoc == loc {
+			res.add(el+".content_loc", loc, "must not be same as url of page")
+		}
+	}
+
+	if vid.PlayerLoc != nil {
+		checkLoc(res, el+".player_loc", loc, *vid.PlayerLoc, "")
+	}
+
+	if vid.Duration != nil {
+		d, err := strconv.Atoi(*vid.Duration)
+		if err != nil || d < 1 || d > _maxDuration {
+			res.add(el+".duration", loc, "must be an integer between 1 and %d seconds, got %q", _maxDuration, *vid.Duration)
+		}
+	}
+
+	if vid.Rating != nil {
+		r, err := strconv.ParseFloat(*vid.Rating, 64)
+		if err != nil || r < 0 || r > 5 {
+			res.add(el+".rating", loc, "must be a number between 0.0 and 5.0, got %q", *vid.Rating)
+		}
+	}
+
+	if vid.ViewCount != nil {
+		if v, err := strconv.ParseInt(*vid.ViewCount, 10, 64); err != nil || v < 0 {
+			res.add(el+".view_count", loc, "must be a non-negative integer, got %q", *vid.ViewCount)
+		}
+	}
+
+	if vid.ExpirationDate != nil && !isW3CDatetime(*vid.ExpirationDate) {
+		res.add(el+".expiration_date", loc, "invalid W3C datetime %q", *vid.ExpirationDate)
+	}
+
+	if vid.PublicationDate != nil && !isW3CDatetime(*vid.PublicationDate) {
+		res.add(el+".publication_date", loc, "invalid W3C datetime %q", *vid.PublicationDate)
+	}
+
+	checkYesNo(res, el+".family_friendly", loc, vid.FamilyFriendly)
+	checkYesNo(res, el+".requires_subscription", loc, vid.RequiresSubscription)
+	checkYesNo(res, el+".live", loc, vid.Live)
+
+	if r := vid.Restriction; r != nil {
+		if r.Relationship == nil {
+			res.add(el+".restriction", loc, "relationship attribute is required")
+		} else if *r.Relationship != "allow" && *r.Relationship != "deny" {
+			res.add(el+".restriction", loc, "relationship must be allow or deny, got %q", *r.Relationship)
+		}
+
+		for _, code := range strings.Fields(r.Countries) {
+			if !isCountryCode(code) {
+				res.add(el+".restriction", loc, "invalid ISO 3166 country code %q", code)
+			}
+		}
+	}
+}
+
+func validateNews(res *Result, el, loc string, n *news) {
+	if n.Publication == nil {
+		res.add(el, loc, "publication is required")
+	} else {
+		if isBlank(n.Publication.Name) {
+			res.add(el+".publication", loc, "name is required")
+		}
+
+		if n.Publication.Language == nil {
+			res.add(el+".publication", loc, "language is required")
+		} else if !isLanguageCode(*n.Publication.Language) {
+			res.add(el+".publication.language", loc, "invalid ISO 639 language code %q", *n.Publication.Language)
+		}
+	}
+
+	if n.PublicationDate == nil {
+		res.add(el, loc, "publication_date is required")
+	} else if !isW3CDatetime(*n.PublicationDate) {
+		res.add(el+".publication_date", loc, "invalid W3C datetime %q", *n.PublicationDate)
+	}
+
+	if isBlank(n.Title) {
+		res.add(el, loc, "title is required")
+	}
+
+	if n.Keywords != nil {
+		for _, k := range strings.Split(*n.Keywords, ",") {
+			if strings.TrimSpace(k) == "" {
+				res.add(el+".keywords", loc, "must be comma separated non-empty keywords, got %q", *n.Keywords)
+				break
+			}
+		}
+	}
+}
+
+func validateSitemapIndex(res *Result, idx *sitemapIndex, opts Options) {
+	res.Entries = len(idx.Sitemaps)
+
+	if res.Entries > opts.MaxURLs {
+		res.add("sitemapindex", "", "contains %d sitemaps, limit is %d", res.Entries, opts.MaxURLs)
+	}
+
+	for i, sm := range idx.Sitemaps {
+		el := fmt.Sprintf("sitemap[%d]", i)
+		loc := deref(sm.Loc)
+
+		if sm.Loc == nil {
+			res.add(el, "", "loc is required")
+		} else {
+			checkLoc(res, el+".loc", loc, loc, opts.Host)
+		}
+
+		if sm.LastMod != nil && !isW3CDatetime(*sm.LastMod) {
+			res.add(el+".lastmod", loc, "invalid W3C datetime %q", *sm.LastMod)
+		}
+	}
+}
+
+func checkLoc(res *Result, el, loc, link, host string) {
+	if len(link) > _maxLocLen {
+		res.add(el, loc, "length %d exceeds limit of %d characters", len(link), _maxLocLen)
+	}
+
+	u, ok := absoluteURL(link)
+	if !ok {
+		res.add(el, loc, "must be an absolute http or https url, got %q", link)
+		return
+	}
+
+	if host != "" && !strings.EqualFold(u.Host, host) {
+		res.add(el, loc, "host %q is not same as %q", u.Host, host)
+	}
+}
+
+func checkYesNo(res *Result, el, loc string, val *string) {
+	if val != nil && *val != "yes" && *val != "no" {
+		res.add(el, loc, "must be yes or no, got %q", *val)
+	}
+}
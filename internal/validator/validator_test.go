@@ -0,0 +1,205 @@
+package validator
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Ja7ad/meilisitemap/config"
+	"github.com/Ja7ad/meilisitemap/internal/logger"
+	"github.com/Ja7ad/meilisitemap/internal/sitemap"
+	"github.com/klauspost/compress/gzip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validURLSet = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"
+  xmlns:image="http://www.google.com/schemas/sitemap-image/1.1"
+  xmlns:video="http://www.google.com/schemas/sitemap-video/1.1"
+  xmlns:news="http://www.google.com/schemas/sitemap-news/0.9">
+  <url>
+    <loc>https://example.com/movies/anatomy-of-a-fall</loc>
+    <lastmod>2024-01-02T15:04:05+00:00</lastmod>
+    <changefreq>daily</changefreq>
+    <priority>0.8</priority>
+    <image:image><image:loc>https://cdn.example.com/anatomy.jpg</image:loc></image:image>
+    <video:video>
+      <video:thumbnail_loc>https://cdn.example.com/anatomy.jpg</video:thumbnail_loc>
+      <video:title>Anatomy of a Fall</video:title>
+      <video:description>Trailer</video:description>
+      <video:content_loc>https://cdn.example.com/anatomy.mp4</video:content_loc>
+      <video:duration>120</video:duration>
+      <video:rating>4.2</video:rating>
+      <video:restriction relationship="allow">FR US</video:restriction>
+      <video:family_friendly>yes</video:family_friendly>
+    </video:video>
+    <news:news>
+      <news:publication><news:name>Movies</news:name><news:language>en</news:language></news:publication>
+      <news:publication_date>2024-01-02</news:publication_date>
+      <news:title>Anatomy of a Fall</news:title>
+      <news:keywords>court, drama</news:keywords>
+    </news:news>
+  </url>
+</urlset>`
+
+const invalidURLSet = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"
+  xmlns:video="http://www.google.com/schemas/sitemap-video/1.1"
+  xmlns:news="http://www.google.com/schemas/sitemap-news/0.9">
+  <url>
+    <loc>/movies/anatomy-of-a-fall</loc>
+    <lastmod>02/01/2024</lastmod>
+    <changefreq>sometimes</changefreq>
+    <priority>1.5</priority>
+  </url>
+  <url>
+    <loc>https://other.com/movies/past-lives</loc>
+    <video:video>
+      <video:title>Past Lives</video:title>
+      <video:duration>0</video:duration>
+      <video:rating>10</video:rating>
+      <video:restriction>USA</video:restriction>
+      <video:live>true</video:live>
+    </video:video>
+    <news:news>
+      <news:publication><news:name>Movies</news:name><news:language>english</news:language></news:publication>
+      <news:keywords>court,,drama</news:keywords>
+    </news:news>
+  </url>
+  <url>
+    <loc>https://other.com/movies/past-lives</loc>
+  </url>
+</urlset>`
+
+const validIndex = `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>https://example.com/sitemaps/movies.xml</loc><lastmod>2024-01-02</lastmod></sitemap>
+  <sitemap><loc>https://example.com/sitemaps/series.xml.gz</loc></sitemap>
+</sitemapindex>`
+
+func TestValidate(t *testing.T) {
+	res, err := Validate([]byte(validURLSet), Options{Host: "example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, KindURLSet, res.Kind)
+	assert.Equal(t, 1, res.Entries)
+	assert.Empty(t, res.Problems)
+	assert.NoError(t, res.Err())
+
+	res, err = Validate([]byte(validIndex), Options{Host: "example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, KindSitemapIndex, res.Kind)
+	assert.Equal(t, 2, res.Entries)
+	assert.Empty(t, res.Problems)
+}
+
+func TestValidateProblems(t *testing.T) {
+	res, err := Validate([]byte(invalidURLSet), Options{Host: "example.com", MaxURLs: 2})
+	require.NoError(t, err)
+	assert.ErrorIs(t, res.Err(), ErrNotConform)
+
+	problems := make([]string, 0)
+	for _, p := range res.Problems {
+		problems = append(problems, p.Element)
+	}
+
+	assert.ElementsMatch(t, []string{
+		"urlset",
+		"url[0].loc",
+		"url[0].lastmod",
+		"url[0].changefreq",
+		"url[0].priority",
+		"url[1].loc",
+		"url[1].video[0]",
+		"url[1].video[0]",
+		"url[1].video[0]",
+		"url[1].video[0].duration",
+		"url[1].video[0].rating",
+		"url[1].video[0].live",
+		"url[1].video[0].restriction",
+		"url[1].video[0].restriction",
+		"url[1].news[0].publication.language",
+		"url[1].news[0]",
+		"url[1].news[0]",
+		"url[1].news[0].keywords",
+		"url[2].loc",
+		"url[2].loc",
+	}, problems)
+}
+
+func TestValidateUnknownRoot(t *testing.T) {
+	_, err := Validate([]byte(`<rss version="2.0"></rss>`), Options{})
+	assert.ErrorIs(t, err, ErrUnknownRoot)
+
+	_, err = Validate([]byte(`not xml`), Options{})
+	assert.Error(t, err)
+}
+
+func TestValidateFileCompressed(t *testing.T) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(validURLSet))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	path := filepath.Join(t.TempDir(), "movies.xml.gz")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+
+	res, err := ValidateFile(path, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, len(validURLSet), res.Bytes)
+	assert.Empty(t, res.Problems)
+}
+
+func TestValidateGeneratedSitemap(t *testing.T) {
+	cfg := &config.SitemapConfig{
+		Sitemap:     true,
+		BaseAddress: "https://example.com/movies",
+		FieldMap: &config.FieldMapConfig{
+			UniqueField: "title",
+			LastMod:     "created_at",
+			ChangeFreq:  config.Weekly,
+			Priority:    config.Highest,
+			Image: &config.ImageConfig{
+				Loc: "poster|https://cdn.example.com/images",
+			},
+			Video: &config.VideoConfig{
+				ThumbnailLoc:            "poster|https://cdn.example.com/images",
+				Title:                   "title",
+				Description:             "description",
+				PlayerLoc:               "player",
+				PlayerAutoPlay:          "autoplay",
+				Restriction:             "countries",
+				RestrictionRelationship: "relationship",
+				RequiresSubscription:    "premium",
+			},
+		},
+	}
+
+	docs := []map[string]any{
+		{
+			"title":        "Anatomy of a Fall",
+			"created_at":   "2024-01-02T15:04:05Z",
+			"poster":       "anatomy.jpg",
+			"description":  "A woman is suspected of her husband's murder.",
+			"player":       "https://example.com/player?movie=anatomy",
+			"autoplay":     true,
+			"countries":    "FR US",
+			"relationship": "allow",
+			"premium":      false,
+		},
+	}
+
+	sm := sitemap.New("https://example.com/stylesheets/style1.xsl", map[string]*config.SitemapConfig{"movies": cfg}, logger.DefaultLogger)
+	b, err := sm.CreateSitemap(context.Background(), "movies", docs)
+	require.NoError(t, err)
+	require.True(t, strings.Contains(string(b), `relationship="allow"`))
+
+	res, err := Validate(b, Options{Host: "example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Entries)
+	assert.Empty(t, res.Problems)
+}
@@ -2,6 +2,9 @@
 	<xsl:stylesheet version="2.0"
 		xmlns:html="http://www.w3.org/TR/REC-html40"
 		xmlns:image="http://www.google.com/schemas/sitemap-image/1.1"
+		xmlns:video="http://www.google.com/schemas/sitemap-video/1.1"
+		xmlns:news="http://www.google.com/schemas/sitemap-news/0.9"
+		xmlns:atom="http://www.w3.org/2005/Atom"
 		xmlns:sitemap="http://www.sitemaps.org/schemas/sitemap/0.9"
 		xmlns:xsl="http://www.w3.org/1999/XSL/Transform">
 	<xsl:output method="html" version="1.0" encoding="UTF-8" indent="yes"/>
@@ -65,6 +68,10 @@
 				thead th {
 					border-bottom: 1px solid #000;
 				}
+				.ext {
+					display: block;
+					color: #777;
+				}
 			</style>
 		</head>
 		<body>
@@ -102,21 +109,21 @@
 					</tbody>
 				</table>
 			</xsl:if>
-			<xsl:if test="count(sitemap:sitemapindex/sitemap:sitemap) &lt; 1">
+			<xsl:if test="count(sitemap:urlset/sitemap:url) &gt; 0">
 				<p class="expl">
 					This XML Sitemap contains <xsl:value-of select="count(sitemap:urlset/sitemap:url)"/> URLs.
 				</p>
 				<table id="sitemap" cellpadding="3">
 					<thead>
 					<tr>
-						<th width="80%">URL</th>
+						<th width="65%">URL</th>
 						<th width="5%">Images</th>
+						<th width="5%">Videos</th>
+						<th width="10%">News</th>
 						<th title="Last Modification Time" width="15%">Last Mod.</th>
 					</tr>
 					</thead>
 					<tbody>
-					<xsl:variable name="lower" select="'abcdefghijklmnopqrstuvwxyz'"/>
-					<xsl:variable name="upper" select="'ABCDEFGHIJKLMNOPQRSTUVWXYZ'"/>
 					<xsl:for-each select="sitemap:urlset/sitemap:url">
 						<tr>
 							<td>
@@ -126,10 +133,31 @@
 								<a href="{$itemURL}">
 									<xsl:value-of select="sitemap:loc"/>
 								</a>
+								<xsl:for-each select="image:image">
+									<a class="ext" href="{image:loc}">
+										<xsl:value-of select="image:loc"/>
+									</a>
+								</xsl:for-each>
+								<xsl:for-each select="video:video">
+									<a class="ext" href="{video:content_loc}{video:player_loc}">
+										<xsl:value-of select="video:title"/>
+									</a>
+								</xsl:for-each>
 							</td>
 							<td>
 								<xsl:value-of select="count(image:image)"/>
 							</td>
+							<td>
+								<xsl:value-of select="count(video:video)"/>
+							</td>
+							<td>
+								<xsl:if test="news:news">
+									<xsl:value-of select="news:news/news:title"/>
+									<span class="ext">
+										<xsl:value-of select="news:news/news:publication/news:name"/>
+									</span>
+								</xsl:if>
+							</td>
 							<td>
 								<xsl:value-of select="concat(substring(sitemap:lastmod,0,11),concat(' ', substring(sitemap:lastmod,12,5)),concat(' ', substring(sitemap:lastmod,20,6)))"/>
 							</td>
@@ -138,6 +166,72 @@
 					</tbody>
 				</table>
 			</xsl:if>
+			<xsl:if test="rss/channel">
+				<p class="expl">
+					This RSS feed of <a href="{rss/channel/link}"><xsl:value-of select="rss/channel/title"/></a>
+					contains <xsl:value-of select="count(rss/channel/item)"/> items.
+				</p>
+				<table id="sitemap" cellpadding="3">
+					<thead>
+					<tr>
+						<th width="40%">Title</th>
+						<th width="45%">Link</th>
+						<th width="15%">Category</th>
+					</tr>
+					</thead>
+					<tbody>
+					<xsl:for-each select="rss/channel/item">
+						<tr>
+							<td>
+								<xsl:value-of select="title"/>
+								<span class="ext">
+									<xsl:value-of select="description"/>
+								</span>
+							</td>
+							<td>
+								<a href="{link}"><xsl:value-of select="link"/></a>
+							</td>
+							<td>
+								<xsl:value-of select="category"/>
+							</td>
+						</tr>
+					</xsl:for-each>
+					</tbody>
+				</table>
+			</xsl:if>
+			<xsl:if test="atom:feed">
+				<p class="expl">
+					This Atom feed of <a href="{atom:feed/atom:link[@rel='alternate']/@href}"><xsl:value-of select="atom:feed/atom:title"/></a>
+					contains <xsl:value-of select="count(atom:feed/atom:entry)"/> entries.
+				</p>
+				<table id="sitemap" cellpadding="3">
+					<thead>
+					<tr>
+						<th width="40%">Title</th>
+						<th width="45%">Link</th>
+						<th width="15%">Updated</th>
+					</tr>
+					</thead>
+					<tbody>
+					<xsl:for-each select="atom:feed/atom:entry">
+						<tr>
+							<td>
+								<xsl:value-of select="atom:title"/>
+								<span class="ext">
+									<xsl:value-of select="atom:summary"/>
+								</span>
+							</td>
+							<td>
+								<a href="{atom:link[@rel='alternate']/@href}"><xsl:value-of select="atom:link[@rel='alternate']/@href"/></a>
+							</td>
+							<td>
+								<xsl:value-of select="concat(substring(atom:updated,0,11),concat(' ', substring(atom:updated,12,5)))"/>
+							</td>
+						</tr>
+					</xsl:for-each>
+					</tbody>
+				</table>
+			</xsl:if>
 		</div>
 		</body>
 		</html>
@@ -2,6 +2,10 @@
 <xsl:stylesheet version="1.0"
                 xmlns:html="http://www.w3.org/TR/REC-html40"
                 xmlns:sitemap="http://www.sitemaps.org/schemas/sitemap/0.9"
+                xmlns:image="http://www.google.com/schemas/sitemap-image/1.1"
+                xmlns:video="http://www.google.com/schemas/sitemap-video/1.1"
+                xmlns:news="http://www.google.com/schemas/sitemap-news/0.9"
+                xmlns:atom="http://www.w3.org/2005/Atom"
                 xmlns:xsl="http://www.w3.org/1999/XSL/Transform">
 	<xsl:output method="html" version="1.0" encoding="UTF-8" indent="yes" />
 	<xsl:template match="/">
@@ -57,6 +61,11 @@
 					a {
 						color:black;
 					}
+
+					.ext {
+						display:block;
+						color:gray;
+					}
 				</style>
 			</head>
 			<body>
@@ -88,6 +97,9 @@
 			<table cellpadding="5">
 				<tr style="border-bottom:1px black solid;">
 					<th>URL</th>
+					<th>Images</th>
+					<th>Videos</th>
+					<th>News</th>
 					<th>Priority</th>
 					<th>Change frequency</th>
 					<th>Last modified (GMT)</th>
@@ -106,6 +118,30 @@
 							<a href="{$itemURL}">
 								<xsl:value-of select="sitemap:loc"/>
 							</a>
+							<xsl:for-each select="image:image">
+								<a class="ext" href="{image:loc}">
+									<xsl:value-of select="image:loc"/>
+								</a>
+							</xsl:for-each>
+							<xsl:for-each select="video:video">
+								<a class="ext" href="{video:content_loc}{video:player_loc}">
+									<xsl:value-of select="video:title"/>
+								</a>
+							</xsl:for-each>
+						</td>
+						<td>
+							<xsl:value-of select="count(image:image)"/>
+						</td>
+						<td>
+							<xsl:value-of select="count(video:video)"/>
+						</td>
+						<td>
+							<xsl:if test="news:news">
+								<xsl:value-of select="news:news/news:title"/>
+								<span class="ext">
+									<xsl:value-of select="news:news/news:publication_date"/>
+								</span>
+							</xsl:if>
 						</td>
 						<td>
 							<xsl:value-of select="concat(sitemap:priority*100,'%')"/>
@@ -160,4 +196,83 @@
 			</table>
 		</div>
 	</xsl:template>
-</xsl:stylesheet>
\ No newline at end of file
+
+
+	<xsl:template match="rss">
+        <h1>RSS Feed</h1>
+        <div id="intro">
+            <p>
+                This RSS feed of <a href="{channel/link}"><xsl:value-of select="channel/title"/></a> contains <xsl:value-of select="count(channel/item)"/> items, subscribe to it with your feed reader.
+            </p>
+            <p>
+                <xsl:value-of select="channel/description"/>
+            </p>
+        </div>
+		<div id="content">
+			<table cellpadding="5">
+				<tr style="border-bottom:1px black solid;">
+					<th>Title</th>
+					<th>Link</th>
+					<th>Category</th>
+				</tr>
+				<xsl:for-each select="channel/item">
+					<tr>
+						<xsl:if test="position() mod 2 != 1">
+							<xsl:attribute  name="class">high</xsl:attribute>
+						</xsl:if>
+						<td>
+							<xsl:value-of select="title"/>
+						</td>
+						<td>
+							<a href="{link}">
+								<xsl:value-of select="link"/>
+							</a>
+						</td>
+						<td>
+							<xsl:value-of select="category"/>
+						</td>
+					</tr>
+				</xsl:for-each>
+			</table>
+		</div>
+	</xsl:template>
+
+	<xsl:template match="atom:feed">
+        <h1>Atom Feed</h1>
+        <div id="intro">
+            <p>
+                This Atom feed of <a href="{atom:link[@rel='alternate']/@href}"><xsl:value-of select="atom:title"/></a> contains <xsl:value-of select="count(atom:entry)"/> entries, subscribe to it with your feed reader.
+            </p>
+            <p>
+                <xsl:value-of select="atom:subtitle"/>
+            </p>
+        </div>
+		<div id="content">
+			<table cellpadding="5">
+				<tr style="border-bottom:1px black solid;">
+					<th>Title</th>
+					<th>Link</th>
+					<th>Updated</th>
+				</tr>
+				<xsl:for-each select="atom:entry">
+					<tr>
+						<xsl:if test="position() mod 2 != 1">
+							<xsl:attribute  name="class">high</xsl:attribute>
+						</xsl:if>
+						<td>
+							<xsl:value-of select="atom:title"/>
+						</td>
+						<td>
+							<a href="{atom:link[@rel='alternate']/@href}">
+								<xsl:value-of select="atom:link[@rel='alternate']/@href"/>
+							</a>
+						</td>
+						<td>
+							<xsl:value-of select="atom:updated"/>
+						</td>
+					</tr>
+				</xsl:for-each>
+			</table>
+		</div>
+	</xsl:template>
+</xsl:stylesheet>
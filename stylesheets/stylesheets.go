@@ -0,0 +1,7 @@
+// Package stylesheets bundles XSL stylesheets which render sitemaps as html in browsers.
+package stylesheets
+
+import "embed"
+
+//go:embed *.xsl
+var FS embed.FS